@@ -23,6 +23,176 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type TagAttribution int32
+
+const (
+	// the first tag takes the whole duration
+	TagAttribution_ATTRIBUTION_FIRST TagAttribution = 0
+	// the duration is split evenly between the tags
+	TagAttribution_ATTRIBUTION_EVEN TagAttribution = 1
+	// tags are weighted like "work:0.7" with a weight between 0 and 1, tags without a weight split the rest evenly
+	TagAttribution_ATTRIBUTION_WEIGHTED TagAttribution = 2
+	// every tag takes the whole duration, so the time of all categories can exceed the tracked time
+	TagAttribution_ATTRIBUTION_FULL TagAttribution = 3
+)
+
+// Enum value maps for TagAttribution.
+var (
+	TagAttribution_name = map[int32]string{
+		0: "ATTRIBUTION_FIRST",
+		1: "ATTRIBUTION_EVEN",
+		2: "ATTRIBUTION_WEIGHTED",
+		3: "ATTRIBUTION_FULL",
+	}
+	TagAttribution_value = map[string]int32{
+		"ATTRIBUTION_FIRST":    0,
+		"ATTRIBUTION_EVEN":     1,
+		"ATTRIBUTION_WEIGHTED": 2,
+		"ATTRIBUTION_FULL":     3,
+	}
+)
+
+func (x TagAttribution) Enum() *TagAttribution {
+	p := new(TagAttribution)
+	*p = x
+	return p
+}
+
+func (x TagAttribution) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TagAttribution) Descriptor() protoreflect.EnumDescriptor {
+	return file_v1_api_proto_enumTypes[0].Descriptor()
+}
+
+func (TagAttribution) Type() protoreflect.EnumType {
+	return &file_v1_api_proto_enumTypes[0]
+}
+
+func (x TagAttribution) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TagAttribution.Descriptor instead.
+func (TagAttribution) EnumDescriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{0}
+}
+
+type OverlapPolicy int32
+
+const (
+	// overlapping events are all counted in full
+	OverlapPolicy_OVERLAP_RAW OverlapPolicy = 0
+	// the event that started last takes the overlapping time
+	OverlapPolicy_OVERLAP_INNERMOST OverlapPolicy = 1
+	// the shortest event takes the overlapping time
+	OverlapPolicy_OVERLAP_SHORTEST OverlapPolicy = 2
+	// the event whose first tag comes first in the tag priority takes the overlapping time
+	OverlapPolicy_OVERLAP_TAG_PRIORITY OverlapPolicy = 3
+	// the overlapping time is split evenly between the events
+	OverlapPolicy_OVERLAP_SPLIT OverlapPolicy = 4
+)
+
+// Enum value maps for OverlapPolicy.
+var (
+	OverlapPolicy_name = map[int32]string{
+		0: "OVERLAP_RAW",
+		1: "OVERLAP_INNERMOST",
+		2: "OVERLAP_SHORTEST",
+		3: "OVERLAP_TAG_PRIORITY",
+		4: "OVERLAP_SPLIT",
+	}
+	OverlapPolicy_value = map[string]int32{
+		"OVERLAP_RAW":          0,
+		"OVERLAP_INNERMOST":    1,
+		"OVERLAP_SHORTEST":     2,
+		"OVERLAP_TAG_PRIORITY": 3,
+		"OVERLAP_SPLIT":        4,
+	}
+)
+
+func (x OverlapPolicy) Enum() *OverlapPolicy {
+	p := new(OverlapPolicy)
+	*p = x
+	return p
+}
+
+func (x OverlapPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OverlapPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_v1_api_proto_enumTypes[1].Descriptor()
+}
+
+func (OverlapPolicy) Type() protoreflect.EnumType {
+	return &file_v1_api_proto_enumTypes[1]
+}
+
+func (x OverlapPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OverlapPolicy.Descriptor instead.
+func (OverlapPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{1}
+}
+
+// Breakdown
+type BucketSize int32
+
+const (
+	BucketSize_BUCKET_DAY BucketSize = 0
+	// weeks start on monday, as in ISO 8601
+	BucketSize_BUCKET_WEEK    BucketSize = 1
+	BucketSize_BUCKET_MONTH   BucketSize = 2
+	BucketSize_BUCKET_QUARTER BucketSize = 3
+)
+
+// Enum value maps for BucketSize.
+var (
+	BucketSize_name = map[int32]string{
+		0: "BUCKET_DAY",
+		1: "BUCKET_WEEK",
+		2: "BUCKET_MONTH",
+		3: "BUCKET_QUARTER",
+	}
+	BucketSize_value = map[string]int32{
+		"BUCKET_DAY":     0,
+		"BUCKET_WEEK":    1,
+		"BUCKET_MONTH":   2,
+		"BUCKET_QUARTER": 3,
+	}
+)
+
+func (x BucketSize) Enum() *BucketSize {
+	p := new(BucketSize)
+	*p = x
+	return p
+}
+
+func (x BucketSize) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BucketSize) Descriptor() protoreflect.EnumDescriptor {
+	return file_v1_api_proto_enumTypes[2].Descriptor()
+}
+
+func (BucketSize) Type() protoreflect.EnumType {
+	return &file_v1_api_proto_enumTypes[2]
+}
+
+func (x BucketSize) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BucketSize.Descriptor instead.
+func (BucketSize) EnumDescriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{2}
+}
+
 type Interval struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
@@ -91,7 +261,13 @@ type Event struct {
 	//	*Event_Relative
 	//	*Event_Absolute
 	//	*Event_None
-	Trigger       isEvent_Trigger `protobuf_oneof:"trigger"`
+	Trigger isEvent_Trigger `protobuf_oneof:"trigger"`
+	// the event spans whole days instead of starting at a time
+	AllDay bool `protobuf:"varint,11,opt,name=all_day,json=allDay,proto3" json:"all_day,omitempty"`
+	// the event is a label that doesn't consume time, its duration is zero
+	Background bool `protobuf:"varint,12,opt,name=background,proto3" json:"background,omitempty"`
+	// how the duration is attributed to the tags, empty for untagged events
+	Attribution   []*Attribution `protobuf:"bytes,13,rep,name=attribution,proto3" json:"attribution,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -209,6 +385,27 @@ func (x *Event) GetNone() bool {
 	return false
 }
 
+func (x *Event) GetAllDay() bool {
+	if x != nil {
+		return x.AllDay
+	}
+	return false
+}
+
+func (x *Event) GetBackground() bool {
+	if x != nil {
+		return x.Background
+	}
+	return false
+}
+
+func (x *Event) GetAttribution() []*Attribution {
+	if x != nil {
+		return x.Attribution
+	}
+	return nil
+}
+
 type isEvent_Trigger interface {
 	isEvent_Trigger()
 }
@@ -231,6 +428,59 @@ func (*Event_Absolute) isEvent_Trigger() {}
 
 func (*Event_None) isEvent_Trigger() {}
 
+type Attribution struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// an index for the lookup table of tag names, weights like "work:0.7" are stripped from the name with the weighted attribution
+	Tag           uint32               `protobuf:"varint,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Duration      *durationpb.Duration `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attribution) Reset() {
+	*x = Attribution{}
+	mi := &file_v1_api_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attribution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attribution) ProtoMessage() {}
+
+func (x *Attribution) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attribution.ProtoReflect.Descriptor instead.
+func (*Attribution) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Attribution) GetTag() uint32 {
+	if x != nil {
+		return x.Tag
+	}
+	return 0
+}
+
+func (x *Attribution) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
 // Calendar
 type CalendarRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -240,7 +490,7 @@ type CalendarRequest struct {
 
 func (x *CalendarRequest) Reset() {
 	*x = CalendarRequest{}
-	mi := &file_v1_api_proto_msgTypes[2]
+	mi := &file_v1_api_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -252,7 +502,7 @@ func (x *CalendarRequest) String() string {
 func (*CalendarRequest) ProtoMessage() {}
 
 func (x *CalendarRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_api_proto_msgTypes[2]
+	mi := &file_v1_api_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -265,7 +515,7 @@ func (x *CalendarRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CalendarRequest.ProtoReflect.Descriptor instead.
 func (*CalendarRequest) Descriptor() ([]byte, []int) {
-	return file_v1_api_proto_rawDescGZIP(), []int{2}
+	return file_v1_api_proto_rawDescGZIP(), []int{3}
 }
 
 type CalendarResponse struct {
@@ -277,7 +527,7 @@ type CalendarResponse struct {
 
 func (x *CalendarResponse) Reset() {
 	*x = CalendarResponse{}
-	mi := &file_v1_api_proto_msgTypes[3]
+	mi := &file_v1_api_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -289,7 +539,7 @@ func (x *CalendarResponse) String() string {
 func (*CalendarResponse) ProtoMessage() {}
 
 func (x *CalendarResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_api_proto_msgTypes[3]
+	mi := &file_v1_api_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -302,7 +552,7 @@ func (x *CalendarResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CalendarResponse.ProtoReflect.Descriptor instead.
 func (*CalendarResponse) Descriptor() ([]byte, []int) {
-	return file_v1_api_proto_rawDescGZIP(), []int{3}
+	return file_v1_api_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *CalendarResponse) GetSources() []*CalendarResponse_Source {
@@ -313,17 +563,130 @@ func (x *CalendarResponse) GetSources() []*CalendarResponse_Source {
 }
 
 // Events
+type TextFilter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// values containing the pattern match, ignoring case
+	Pattern string `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// treat the pattern as a regular expression (RE2 syntax) instead
+	Regex         bool `protobuf:"varint,2,opt,name=regex,proto3" json:"regex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextFilter) Reset() {
+	*x = TextFilter{}
+	mi := &file_v1_api_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextFilter) ProtoMessage() {}
+
+func (x *TextFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextFilter.ProtoReflect.Descriptor instead.
+func (*TextFilter) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TextFilter) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *TextFilter) GetRegex() bool {
+	if x != nil {
+		return x.Regex
+	}
+	return false
+}
+
+type Overlap struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Policy OverlapPolicy          `protobuf:"varint,1,opt,name=policy,proto3,enum=OverlapPolicy" json:"policy,omitempty"`
+	// tags in descending priority for OVERLAP_TAG_PRIORITY, unlisted tags rank below them
+	TagPriority   []string `protobuf:"bytes,2,rep,name=tag_priority,json=tagPriority,proto3" json:"tag_priority,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Overlap) Reset() {
+	*x = Overlap{}
+	mi := &file_v1_api_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Overlap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Overlap) ProtoMessage() {}
+
+func (x *Overlap) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Overlap.ProtoReflect.Descriptor instead.
+func (*Overlap) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Overlap) GetPolicy() OverlapPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return OverlapPolicy_OVERLAP_RAW
+}
+
+func (x *Overlap) GetTagPriority() []string {
+	if x != nil {
+		return x.TagPriority
+	}
+	return nil
+}
+
 type EventsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Interval      *Interval              `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
-	Timezone      string                 `protobuf:"bytes,2,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Interval *Interval              `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	Timezone string                 `protobuf:"bytes,2,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// only events matching every set filter are returned
+	Location    *TextFilter `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Description *TextFilter `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	// events are returned as the parts that count towards the statistics, an event may be split into multiple parts sharing its id
+	Overlap       *Overlap       `protobuf:"bytes,5,opt,name=overlap,proto3" json:"overlap,omitempty"`
+	Attribution   TagAttribution `protobuf:"varint,6,opt,name=attribution,proto3,enum=TagAttribution" json:"attribution,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EventsRequest) Reset() {
 	*x = EventsRequest{}
-	mi := &file_v1_api_proto_msgTypes[4]
+	mi := &file_v1_api_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -335,7 +698,7 @@ func (x *EventsRequest) String() string {
 func (*EventsRequest) ProtoMessage() {}
 
 func (x *EventsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_api_proto_msgTypes[4]
+	mi := &file_v1_api_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -348,7 +711,7 @@ func (x *EventsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
 func (*EventsRequest) Descriptor() ([]byte, []int) {
-	return file_v1_api_proto_rawDescGZIP(), []int{4}
+	return file_v1_api_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *EventsRequest) GetInterval() *Interval {
@@ -365,6 +728,34 @@ func (x *EventsRequest) GetTimezone() string {
 	return ""
 }
 
+func (x *EventsRequest) GetLocation() *TextFilter {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *EventsRequest) GetDescription() *TextFilter {
+	if x != nil {
+		return x.Description
+	}
+	return nil
+}
+
+func (x *EventsRequest) GetOverlap() *Overlap {
+	if x != nil {
+		return x.Overlap
+	}
+	return nil
+}
+
+func (x *EventsRequest) GetAttribution() TagAttribution {
+	if x != nil {
+		return x.Attribution
+	}
+	return TagAttribution_ATTRIBUTION_FIRST
+}
+
 type EventsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	EventNames    []string               `protobuf:"bytes,1,rep,name=event_names,json=eventNames,proto3" json:"event_names,omitempty"`
@@ -376,7 +767,7 @@ type EventsResponse struct {
 
 func (x *EventsResponse) Reset() {
 	*x = EventsResponse{}
-	mi := &file_v1_api_proto_msgTypes[5]
+	mi := &file_v1_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -388,7 +779,7 @@ func (x *EventsResponse) String() string {
 func (*EventsResponse) ProtoMessage() {}
 
 func (x *EventsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_api_proto_msgTypes[5]
+	mi := &file_v1_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -401,7 +792,7 @@ func (x *EventsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EventsResponse.ProtoReflect.Descriptor instead.
 func (*EventsResponse) Descriptor() ([]byte, []int) {
-	return file_v1_api_proto_rawDescGZIP(), []int{5}
+	return file_v1_api_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *EventsResponse) GetEventNames() []string {
@@ -425,29 +816,37 @@ func (x *EventsResponse) GetEvents() []*Event {
 	return nil
 }
 
-type CalendarResponse_Source struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	CalendarServer string                 `protobuf:"bytes,1,opt,name=calendar_server,json=calendarServer,proto3" json:"calendar_server,omitempty"`
-	Names          []string               `protobuf:"bytes,2,rep,name=names,proto3" json:"names,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+// Stats
+type StatsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Interval *Interval              `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	Timezone string                 `protobuf:"bytes,2,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// only events matching every set filter are counted
+	Location    *TextFilter `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Description *TextFilter `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	// categories left out of the totals and proportions, "Unknown" also covers untracked time
+	Disabled      []string       `protobuf:"bytes,5,rep,name=disabled,proto3" json:"disabled,omitempty"`
+	Overlap       *Overlap       `protobuf:"bytes,6,opt,name=overlap,proto3" json:"overlap,omitempty"`
+	Attribution   TagAttribution `protobuf:"varint,7,opt,name=attribution,proto3,enum=TagAttribution" json:"attribution,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CalendarResponse_Source) Reset() {
-	*x = CalendarResponse_Source{}
-	mi := &file_v1_api_proto_msgTypes[6]
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_v1_api_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CalendarResponse_Source) String() string {
+func (x *StatsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CalendarResponse_Source) ProtoMessage() {}
+func (*StatsRequest) ProtoMessage() {}
 
-func (x *CalendarResponse_Source) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_api_proto_msgTypes[6]
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -458,25 +857,901 @@ func (x *CalendarResponse_Source) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CalendarResponse_Source.ProtoReflect.Descriptor instead.
-func (*CalendarResponse_Source) Descriptor() ([]byte, []int) {
-	return file_v1_api_proto_rawDescGZIP(), []int{3, 0}
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *CalendarResponse_Source) GetCalendarServer() string {
+func (x *StatsRequest) GetInterval() *Interval {
 	if x != nil {
-		return x.CalendarServer
+		return x.Interval
 	}
-	return ""
+	return nil
 }
 
-func (x *CalendarResponse_Source) GetNames() []string {
+func (x *StatsRequest) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *StatsRequest) GetLocation() *TextFilter {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *StatsRequest) GetDescription() *TextFilter {
+	if x != nil {
+		return x.Description
+	}
+	return nil
+}
+
+func (x *StatsRequest) GetDisabled() []string {
+	if x != nil {
+		return x.Disabled
+	}
+	return nil
+}
+
+func (x *StatsRequest) GetOverlap() *Overlap {
+	if x != nil {
+		return x.Overlap
+	}
+	return nil
+}
+
+func (x *StatsRequest) GetAttribution() TagAttribution {
+	if x != nil {
+		return x.Attribution
+	}
+	return TagAttribution_ATTRIBUTION_FIRST
+}
+
+type CategoryStat struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the tag the time is attributed to, "Unknown" for untagged events and untracked time
+	Name string               `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Time *durationpb.Duration `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// the share of the time of all enabled categories
+	Proportion    float64 `protobuf:"fixed64,3,opt,name=proportion,proto3" json:"proportion,omitempty"`
+	Events        uint32  `protobuf:"varint,4,opt,name=events,proto3" json:"events,omitempty"`
+	Disabled      bool    `protobuf:"varint,5,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryStat) Reset() {
+	*x = CategoryStat{}
+	mi := &file_v1_api_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryStat) ProtoMessage() {}
+
+func (x *CategoryStat) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryStat.ProtoReflect.Descriptor instead.
+func (*CategoryStat) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CategoryStat) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CategoryStat) GetTime() *durationpb.Duration {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *CategoryStat) GetProportion() float64 {
+	if x != nil {
+		return x.Proportion
+	}
+	return 0
+}
+
+func (x *CategoryStat) GetEvents() uint32 {
+	if x != nil {
+		return x.Events
+	}
+	return 0
+}
+
+func (x *CategoryStat) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type CategoryNode struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the last segment of the category path, empty for the root
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// the full category path, as in CategoryStat.name
+	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// time of the events in exactly this category
+	Self *durationpb.Duration `protobuf:"bytes,3,opt,name=self,proto3" json:"self,omitempty"`
+	// time of this category and every category below it
+	Subtree *durationpb.Duration `protobuf:"bytes,4,opt,name=subtree,proto3" json:"subtree,omitempty"`
+	// the share of the subtree time of all enabled categories
+	Proportion float64 `protobuf:"fixed64,5,opt,name=proportion,proto3" json:"proportion,omitempty"`
+	// sorted by subtree time, longest first
+	Children      []*CategoryNode `protobuf:"bytes,6,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryNode) Reset() {
+	*x = CategoryNode{}
+	mi := &file_v1_api_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryNode) ProtoMessage() {}
+
+func (x *CategoryNode) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryNode.ProtoReflect.Descriptor instead.
+func (*CategoryNode) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CategoryNode) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CategoryNode) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CategoryNode) GetSelf() *durationpb.Duration {
+	if x != nil {
+		return x.Self
+	}
+	return nil
+}
+
+func (x *CategoryNode) GetSubtree() *durationpb.Duration {
+	if x != nil {
+		return x.Subtree
+	}
+	return nil
+}
+
+func (x *CategoryNode) GetProportion() float64 {
+	if x != nil {
+		return x.Proportion
+	}
+	return 0
+}
+
+func (x *CategoryNode) GetChildren() []*CategoryNode {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type StatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// sorted by time, longest first
+	Categories []*CategoryStat `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"`
+	// the length of the interval without the time of disabled categories
+	Total     *durationpb.Duration `protobuf:"bytes,2,opt,name=total,proto3" json:"total,omitempty"`
+	Tracked   *durationpb.Duration `protobuf:"bytes,3,opt,name=tracked,proto3" json:"tracked,omitempty"`
+	Untracked *durationpb.Duration `protobuf:"bytes,4,opt,name=untracked,proto3" json:"untracked,omitempty"`
+	// the enabled categories split into a hierarchy by the configured separator
+	Tree          *CategoryNode `protobuf:"bytes,5,opt,name=tree,proto3" json:"tree,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_v1_api_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StatsResponse) GetCategories() []*CategoryStat {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetTotal() *durationpb.Duration {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetTracked() *durationpb.Duration {
+	if x != nil {
+		return x.Tracked
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetUntracked() *durationpb.Duration {
+	if x != nil {
+		return x.Untracked
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetTree() *CategoryNode {
+	if x != nil {
+		return x.Tree
+	}
+	return nil
+}
+
+type BreakdownRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Interval *Interval              `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	Timezone string                 `protobuf:"bytes,2,opt,name=timezone,proto3" json:"timezone,omitempty"`
+	// only events matching every set filter are counted
+	Location      *TextFilter    `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	Description   *TextFilter    `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	BucketSize    BucketSize     `protobuf:"varint,5,opt,name=bucket_size,json=bucketSize,proto3,enum=BucketSize" json:"bucket_size,omitempty"`
+	Overlap       *Overlap       `protobuf:"bytes,6,opt,name=overlap,proto3" json:"overlap,omitempty"`
+	Attribution   TagAttribution `protobuf:"varint,7,opt,name=attribution,proto3,enum=TagAttribution" json:"attribution,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BreakdownRequest) Reset() {
+	*x = BreakdownRequest{}
+	mi := &file_v1_api_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BreakdownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakdownRequest) ProtoMessage() {}
+
+func (x *BreakdownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakdownRequest.ProtoReflect.Descriptor instead.
+func (*BreakdownRequest) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BreakdownRequest) GetInterval() *Interval {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *BreakdownRequest) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+func (x *BreakdownRequest) GetLocation() *TextFilter {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *BreakdownRequest) GetDescription() *TextFilter {
+	if x != nil {
+		return x.Description
+	}
+	return nil
+}
+
+func (x *BreakdownRequest) GetBucketSize() BucketSize {
+	if x != nil {
+		return x.BucketSize
+	}
+	return BucketSize_BUCKET_DAY
+}
+
+func (x *BreakdownRequest) GetOverlap() *Overlap {
+	if x != nil {
+		return x.Overlap
+	}
+	return nil
+}
+
+func (x *BreakdownRequest) GetAttribution() TagAttribution {
+	if x != nil {
+		return x.Attribution
+	}
+	return TagAttribution_ATTRIBUTION_FIRST
+}
+
+type Bucket struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the calendar period in the requested timezone, clipped to the requested interval
+	Interval *Interval `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	// the time per tag, each element corresponds to the tag with the same index
+	Time          []*durationpb.Duration `protobuf:"bytes,2,rep,name=time,proto3" json:"time,omitempty"`
+	Untracked     *durationpb.Duration   `protobuf:"bytes,3,opt,name=untracked,proto3" json:"untracked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bucket) Reset() {
+	*x = Bucket{}
+	mi := &file_v1_api_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bucket) ProtoMessage() {}
+
+func (x *Bucket) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bucket.ProtoReflect.Descriptor instead.
+func (*Bucket) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Bucket) GetInterval() *Interval {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *Bucket) GetTime() []*durationpb.Duration {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Bucket) GetUntracked() *durationpb.Duration {
+	if x != nil {
+		return x.Untracked
+	}
+	return nil
+}
+
+type BreakdownResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the tags the time is attributed to, "Unknown" for untagged events
+	Tags          []string  `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	Buckets       []*Bucket `protobuf:"bytes,2,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BreakdownResponse) Reset() {
+	*x = BreakdownResponse{}
+	mi := &file_v1_api_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BreakdownResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakdownResponse) ProtoMessage() {}
+
+func (x *BreakdownResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakdownResponse.ProtoReflect.Descriptor instead.
+func (*BreakdownResponse) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BreakdownResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *BreakdownResponse) GetBuckets() []*Bucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+// UpdateEvents
+type EventUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the id of the event as returned by Events
+	Id uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// the following fields are left unchanged if they are not set
+	Name        *string           `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Location    *string           `protobuf:"bytes,3,opt,name=location,proto3,oneof" json:"location,omitempty"`
+	Description *string           `protobuf:"bytes,4,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Tags        *EventUpdate_Tags `protobuf:"bytes,5,opt,name=tags,proto3" json:"tags,omitempty"`
+	Interval    *Interval         `protobuf:"bytes,6,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Types that are valid to be assigned to Trigger:
+	//
+	//	*EventUpdate_Relative
+	//	*EventUpdate_Absolute
+	//	*EventUpdate_None
+	Trigger       isEventUpdate_Trigger `protobuf_oneof:"trigger"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventUpdate) Reset() {
+	*x = EventUpdate{}
+	mi := &file_v1_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventUpdate) ProtoMessage() {}
+
+func (x *EventUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventUpdate.ProtoReflect.Descriptor instead.
+func (*EventUpdate) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *EventUpdate) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *EventUpdate) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *EventUpdate) GetLocation() string {
+	if x != nil && x.Location != nil {
+		return *x.Location
+	}
+	return ""
+}
+
+func (x *EventUpdate) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *EventUpdate) GetTags() *EventUpdate_Tags {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *EventUpdate) GetInterval() *Interval {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *EventUpdate) GetTrigger() isEventUpdate_Trigger {
+	if x != nil {
+		return x.Trigger
+	}
+	return nil
+}
+
+func (x *EventUpdate) GetRelative() *durationpb.Duration {
+	if x != nil {
+		if x, ok := x.Trigger.(*EventUpdate_Relative); ok {
+			return x.Relative
+		}
+	}
+	return nil
+}
+
+func (x *EventUpdate) GetAbsolute() *timestamppb.Timestamp {
+	if x != nil {
+		if x, ok := x.Trigger.(*EventUpdate_Absolute); ok {
+			return x.Absolute
+		}
+	}
+	return nil
+}
+
+func (x *EventUpdate) GetNone() bool {
+	if x != nil {
+		if x, ok := x.Trigger.(*EventUpdate_None); ok {
+			return x.None
+		}
+	}
+	return false
+}
+
+type isEventUpdate_Trigger interface {
+	isEventUpdate_Trigger()
+}
+
+type EventUpdate_Relative struct {
+	Relative *durationpb.Duration `protobuf:"bytes,7,opt,name=relative,proto3,oneof"`
+}
+
+type EventUpdate_Absolute struct {
+	Absolute *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=absolute,proto3,oneof"`
+}
+
+type EventUpdate_None struct {
+	None bool `protobuf:"varint,9,opt,name=none,proto3,oneof"`
+}
+
+func (*EventUpdate_Relative) isEventUpdate_Trigger() {}
+
+func (*EventUpdate_Absolute) isEventUpdate_Trigger() {}
+
+func (*EventUpdate_None) isEventUpdate_Trigger() {}
+
+type UpdateEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*EventUpdate         `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEventsRequest) Reset() {
+	*x = UpdateEventsRequest{}
+	mi := &file_v1_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEventsRequest) ProtoMessage() {}
+
+func (x *UpdateEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEventsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEventsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UpdateEventsRequest) GetEvents() []*EventUpdate {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type UpdateEventsResponse struct {
+	state         protoimpl.MessageState         `protogen:"open.v1"`
+	Results       []*UpdateEventsResponse_Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEventsResponse) Reset() {
+	*x = UpdateEventsResponse{}
+	mi := &file_v1_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEventsResponse) ProtoMessage() {}
+
+func (x *UpdateEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEventsResponse.ProtoReflect.Descriptor instead.
+func (*UpdateEventsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UpdateEventsResponse) GetResults() []*UpdateEventsResponse_Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type CalendarResponse_Source struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CalendarServer string                 `protobuf:"bytes,1,opt,name=calendar_server,json=calendarServer,proto3" json:"calendar_server,omitempty"`
+	Names          []string               `protobuf:"bytes,2,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CalendarResponse_Source) Reset() {
+	*x = CalendarResponse_Source{}
+	mi := &file_v1_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CalendarResponse_Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalendarResponse_Source) ProtoMessage() {}
+
+func (x *CalendarResponse_Source) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalendarResponse_Source.ProtoReflect.Descriptor instead.
+func (*CalendarResponse_Source) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{4, 0}
+}
+
+func (x *CalendarResponse_Source) GetCalendarServer() string {
+	if x != nil {
+		return x.CalendarServer
+	}
+	return ""
+}
+
+func (x *CalendarResponse_Source) GetNames() []string {
 	if x != nil {
 		return x.Names
 	}
 	return nil
 }
 
+type EventUpdate_Tags struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []string               `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventUpdate_Tags) Reset() {
+	*x = EventUpdate_Tags{}
+	mi := &file_v1_api_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventUpdate_Tags) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventUpdate_Tags) ProtoMessage() {}
+
+func (x *EventUpdate_Tags) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventUpdate_Tags.ProtoReflect.Descriptor instead.
+func (*EventUpdate_Tags) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{16, 0}
+}
+
+func (x *EventUpdate_Tags) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type UpdateEventsResponse_Result struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// error is empty if the update succeeded
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEventsResponse_Result) Reset() {
+	*x = UpdateEventsResponse_Result{}
+	mi := &file_v1_api_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEventsResponse_Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEventsResponse_Result) ProtoMessage() {}
+
+func (x *UpdateEventsResponse_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_api_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEventsResponse_Result.ProtoReflect.Descriptor instead.
+func (*UpdateEventsResponse_Result) Descriptor() ([]byte, []int) {
+	return file_v1_api_proto_rawDescGZIP(), []int{18, 0}
+}
+
+func (x *UpdateEventsResponse_Result) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateEventsResponse_Result) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_v1_api_proto protoreflect.FileDescriptor
 
 const file_v1_api_proto_rawDesc = "" +
@@ -484,7 +1759,7 @@ const file_v1_api_proto_rawDesc = "" +
 	"\fv1/api.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\"j\n" +
 	"\bInterval\x120\n" +
 	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12,\n" +
-	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\"\xef\x02\n" +
+	"\x03end\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x03end\"\xd8\x03\n" +
 	"\x05Event\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\rR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\rR\x04name\x12\x1a\n" +
@@ -496,25 +1771,137 @@ const file_v1_api_proto_rawDesc = "" +
 	"\brelative\x18\b \x01(\v2\x19.google.protobuf.DurationH\x00R\brelative\x128\n" +
 	"\babsolute\x18\t \x01(\v2\x1a.google.protobuf.TimestampH\x00R\babsolute\x12\x14\n" +
 	"\x04none\x18\n" +
-	" \x01(\bH\x00R\x04noneB\t\n" +
-	"\atrigger\"\x11\n" +
+	" \x01(\bH\x00R\x04none\x12\x17\n" +
+	"\aall_day\x18\v \x01(\bR\x06allDay\x12\x1e\n" +
+	"\n" +
+	"background\x18\f \x01(\bR\n" +
+	"background\x12.\n" +
+	"\vattribution\x18\r \x03(\v2\f.AttributionR\vattributionB\t\n" +
+	"\atrigger\"V\n" +
+	"\vAttribution\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\rR\x03tag\x125\n" +
+	"\bduration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bduration\"\x11\n" +
 	"\x0fCalendarRequest\"\x8f\x01\n" +
 	"\x10CalendarResponse\x122\n" +
 	"\asources\x18\x01 \x03(\v2\x18.CalendarResponse.SourceR\asources\x1aG\n" +
 	"\x06Source\x12'\n" +
 	"\x0fcalendar_server\x18\x01 \x01(\tR\x0ecalendarServer\x12\x14\n" +
-	"\x05names\x18\x02 \x03(\tR\x05names\"R\n" +
+	"\x05names\x18\x02 \x03(\tR\x05names\"<\n" +
+	"\n" +
+	"TextFilter\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x14\n" +
+	"\x05regex\x18\x02 \x01(\bR\x05regex\"T\n" +
+	"\aOverlap\x12&\n" +
+	"\x06policy\x18\x01 \x01(\x0e2\x0e.OverlapPolicyR\x06policy\x12!\n" +
+	"\ftag_priority\x18\x02 \x03(\tR\vtagPriority\"\x81\x02\n" +
 	"\rEventsRequest\x12%\n" +
 	"\binterval\x18\x01 \x01(\v2\t.IntervalR\binterval\x12\x1a\n" +
-	"\btimezone\x18\x02 \x01(\tR\btimezone\"e\n" +
+	"\btimezone\x18\x02 \x01(\tR\btimezone\x12'\n" +
+	"\blocation\x18\x03 \x01(\v2\v.TextFilterR\blocation\x12-\n" +
+	"\vdescription\x18\x04 \x01(\v2\v.TextFilterR\vdescription\x12\"\n" +
+	"\aoverlap\x18\x05 \x01(\v2\b.OverlapR\aoverlap\x121\n" +
+	"\vattribution\x18\x06 \x01(\x0e2\x0f.TagAttributionR\vattribution\"e\n" +
 	"\x0eEventsResponse\x12\x1f\n" +
 	"\vevent_names\x18\x01 \x03(\tR\n" +
 	"eventNames\x12\x12\n" +
 	"\x04tags\x18\x02 \x03(\tR\x04tags\x12\x1e\n" +
-	"\x06events\x18\x03 \x03(\v2\x06.EventR\x06events2m\n" +
+	"\x06events\x18\x03 \x03(\v2\x06.EventR\x06events\"\x9c\x02\n" +
+	"\fStatsRequest\x12%\n" +
+	"\binterval\x18\x01 \x01(\v2\t.IntervalR\binterval\x12\x1a\n" +
+	"\btimezone\x18\x02 \x01(\tR\btimezone\x12'\n" +
+	"\blocation\x18\x03 \x01(\v2\v.TextFilterR\blocation\x12-\n" +
+	"\vdescription\x18\x04 \x01(\v2\v.TextFilterR\vdescription\x12\x1a\n" +
+	"\bdisabled\x18\x05 \x03(\tR\bdisabled\x12\"\n" +
+	"\aoverlap\x18\x06 \x01(\v2\b.OverlapR\aoverlap\x121\n" +
+	"\vattribution\x18\a \x01(\x0e2\x0f.TagAttributionR\vattribution\"\xa5\x01\n" +
+	"\fCategoryStat\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12-\n" +
+	"\x04time\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x04time\x12\x1e\n" +
+	"\n" +
+	"proportion\x18\x03 \x01(\x01R\n" +
+	"proportion\x12\x16\n" +
+	"\x06events\x18\x04 \x01(\rR\x06events\x12\x1a\n" +
+	"\bdisabled\x18\x05 \x01(\bR\bdisabled\"\xe5\x01\n" +
+	"\fCategoryNode\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12-\n" +
+	"\x04self\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x04self\x123\n" +
+	"\asubtree\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\asubtree\x12\x1e\n" +
+	"\n" +
+	"proportion\x18\x05 \x01(\x01R\n" +
+	"proportion\x12)\n" +
+	"\bchildren\x18\x06 \x03(\v2\r.CategoryNodeR\bchildren\"\x80\x02\n" +
+	"\rStatsResponse\x12-\n" +
+	"\n" +
+	"categories\x18\x01 \x03(\v2\r.CategoryStatR\n" +
+	"categories\x12/\n" +
+	"\x05total\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x05total\x123\n" +
+	"\atracked\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\atracked\x127\n" +
+	"\tuntracked\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\tuntracked\x12!\n" +
+	"\x04tree\x18\x05 \x01(\v2\r.CategoryNodeR\x04tree\"\xb2\x02\n" +
+	"\x10BreakdownRequest\x12%\n" +
+	"\binterval\x18\x01 \x01(\v2\t.IntervalR\binterval\x12\x1a\n" +
+	"\btimezone\x18\x02 \x01(\tR\btimezone\x12'\n" +
+	"\blocation\x18\x03 \x01(\v2\v.TextFilterR\blocation\x12-\n" +
+	"\vdescription\x18\x04 \x01(\v2\v.TextFilterR\vdescription\x12,\n" +
+	"\vbucket_size\x18\x05 \x01(\x0e2\v.BucketSizeR\n" +
+	"bucketSize\x12\"\n" +
+	"\aoverlap\x18\x06 \x01(\v2\b.OverlapR\aoverlap\x121\n" +
+	"\vattribution\x18\a \x01(\x0e2\x0f.TagAttributionR\vattribution\"\x97\x01\n" +
+	"\x06Bucket\x12%\n" +
+	"\binterval\x18\x01 \x01(\v2\t.IntervalR\binterval\x12-\n" +
+	"\x04time\x18\x02 \x03(\v2\x19.google.protobuf.DurationR\x04time\x127\n" +
+	"\tuntracked\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\tuntracked\"J\n" +
+	"\x11BreakdownResponse\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tags\x12!\n" +
+	"\abuckets\x18\x02 \x03(\v2\a.BucketR\abuckets\"\xa2\x03\n" +
+	"\vEventUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x01R\x04name\x88\x01\x01\x12\x1f\n" +
+	"\blocation\x18\x03 \x01(\tH\x02R\blocation\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x04 \x01(\tH\x03R\vdescription\x88\x01\x01\x12%\n" +
+	"\x04tags\x18\x05 \x01(\v2\x11.EventUpdate.TagsR\x04tags\x12%\n" +
+	"\binterval\x18\x06 \x01(\v2\t.IntervalR\binterval\x127\n" +
+	"\brelative\x18\a \x01(\v2\x19.google.protobuf.DurationH\x00R\brelative\x128\n" +
+	"\babsolute\x18\b \x01(\v2\x1a.google.protobuf.TimestampH\x00R\babsolute\x12\x14\n" +
+	"\x04none\x18\t \x01(\bH\x00R\x04none\x1a\x1a\n" +
+	"\x04Tags\x12\x12\n" +
+	"\x04tags\x18\x01 \x03(\tR\x04tagsB\t\n" +
+	"\atriggerB\a\n" +
+	"\x05_nameB\v\n" +
+	"\t_locationB\x0e\n" +
+	"\f_description\";\n" +
+	"\x13UpdateEventsRequest\x12$\n" +
+	"\x06events\x18\x01 \x03(\v2\f.EventUpdateR\x06events\"~\n" +
+	"\x14UpdateEventsResponse\x126\n" +
+	"\aresults\x18\x01 \x03(\v2\x1c.UpdateEventsResponse.ResultR\aresults\x1a.\n" +
+	"\x06Result\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error*m\n" +
+	"\x0eTagAttribution\x12\x15\n" +
+	"\x11ATTRIBUTION_FIRST\x10\x00\x12\x14\n" +
+	"\x10ATTRIBUTION_EVEN\x10\x01\x12\x18\n" +
+	"\x14ATTRIBUTION_WEIGHTED\x10\x02\x12\x14\n" +
+	"\x10ATTRIBUTION_FULL\x10\x03*z\n" +
+	"\rOverlapPolicy\x12\x0f\n" +
+	"\vOVERLAP_RAW\x10\x00\x12\x15\n" +
+	"\x11OVERLAP_INNERMOST\x10\x01\x12\x14\n" +
+	"\x10OVERLAP_SHORTEST\x10\x02\x12\x18\n" +
+	"\x14OVERLAP_TAG_PRIORITY\x10\x03\x12\x11\n" +
+	"\rOVERLAP_SPLIT\x10\x04*S\n" +
+	"\n" +
+	"BucketSize\x12\x0e\n" +
+	"\n" +
+	"BUCKET_DAY\x10\x00\x12\x0f\n" +
+	"\vBUCKET_WEEK\x10\x01\x12\x10\n" +
+	"\fBUCKET_MONTH\x10\x02\x12\x12\n" +
+	"\x0eBUCKET_QUARTER\x10\x032\x86\x02\n" +
 	"\x0fCalendarService\x12/\n" +
 	"\bCalendar\x12\x10.CalendarRequest\x1a\x11.CalendarResponse\x12)\n" +
-	"\x06Events\x12\x0e.EventsRequest\x1a\x0f.EventsResponseB\x1dB\bApiProtoP\x01Z\x0fcalstats/api/v1b\x06proto3"
+	"\x06Events\x12\x0e.EventsRequest\x1a\x0f.EventsResponse\x12&\n" +
+	"\x05Stats\x12\r.StatsRequest\x1a\x0e.StatsResponse\x122\n" +
+	"\tBreakdown\x12\x11.BreakdownRequest\x1a\x12.BreakdownResponse\x12;\n" +
+	"\fUpdateEvents\x12\x14.UpdateEventsRequest\x1a\x15.UpdateEventsResponseB\x1dB\bApiProtoP\x01Z\x0fcalstats/api/v1b\x06proto3"
 
 var (
 	file_v1_api_proto_rawDescOnce sync.Once
@@ -528,37 +1915,99 @@ func file_v1_api_proto_rawDescGZIP() []byte {
 	return file_v1_api_proto_rawDescData
 }
 
-var file_v1_api_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_v1_api_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_v1_api_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_v1_api_proto_goTypes = []any{
-	(*Interval)(nil),                // 0: Interval
-	(*Event)(nil),                   // 1: Event
-	(*CalendarRequest)(nil),         // 2: CalendarRequest
-	(*CalendarResponse)(nil),        // 3: CalendarResponse
-	(*EventsRequest)(nil),           // 4: EventsRequest
-	(*EventsResponse)(nil),          // 5: EventsResponse
-	(*CalendarResponse_Source)(nil), // 6: CalendarResponse.Source
-	(*timestamppb.Timestamp)(nil),   // 7: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),     // 8: google.protobuf.Duration
+	(TagAttribution)(0),                 // 0: TagAttribution
+	(OverlapPolicy)(0),                  // 1: OverlapPolicy
+	(BucketSize)(0),                     // 2: BucketSize
+	(*Interval)(nil),                    // 3: Interval
+	(*Event)(nil),                       // 4: Event
+	(*Attribution)(nil),                 // 5: Attribution
+	(*CalendarRequest)(nil),             // 6: CalendarRequest
+	(*CalendarResponse)(nil),            // 7: CalendarResponse
+	(*TextFilter)(nil),                  // 8: TextFilter
+	(*Overlap)(nil),                     // 9: Overlap
+	(*EventsRequest)(nil),               // 10: EventsRequest
+	(*EventsResponse)(nil),              // 11: EventsResponse
+	(*StatsRequest)(nil),                // 12: StatsRequest
+	(*CategoryStat)(nil),                // 13: CategoryStat
+	(*CategoryNode)(nil),                // 14: CategoryNode
+	(*StatsResponse)(nil),               // 15: StatsResponse
+	(*BreakdownRequest)(nil),            // 16: BreakdownRequest
+	(*Bucket)(nil),                      // 17: Bucket
+	(*BreakdownResponse)(nil),           // 18: BreakdownResponse
+	(*EventUpdate)(nil),                 // 19: EventUpdate
+	(*UpdateEventsRequest)(nil),         // 20: UpdateEventsRequest
+	(*UpdateEventsResponse)(nil),        // 21: UpdateEventsResponse
+	(*CalendarResponse_Source)(nil),     // 22: CalendarResponse.Source
+	(*EventUpdate_Tags)(nil),            // 23: EventUpdate.Tags
+	(*UpdateEventsResponse_Result)(nil), // 24: UpdateEventsResponse.Result
+	(*timestamppb.Timestamp)(nil),       // 25: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),         // 26: google.protobuf.Duration
 }
 var file_v1_api_proto_depIdxs = []int32{
-	7,  // 0: Interval.start:type_name -> google.protobuf.Timestamp
-	7,  // 1: Interval.end:type_name -> google.protobuf.Timestamp
-	0,  // 2: Event.interval:type_name -> Interval
-	8,  // 3: Event.duration:type_name -> google.protobuf.Duration
-	8,  // 4: Event.relative:type_name -> google.protobuf.Duration
-	7,  // 5: Event.absolute:type_name -> google.protobuf.Timestamp
-	6,  // 6: CalendarResponse.sources:type_name -> CalendarResponse.Source
-	0,  // 7: EventsRequest.interval:type_name -> Interval
-	1,  // 8: EventsResponse.events:type_name -> Event
-	2,  // 9: CalendarService.Calendar:input_type -> CalendarRequest
-	4,  // 10: CalendarService.Events:input_type -> EventsRequest
-	3,  // 11: CalendarService.Calendar:output_type -> CalendarResponse
-	5,  // 12: CalendarService.Events:output_type -> EventsResponse
-	11, // [11:13] is the sub-list for method output_type
-	9,  // [9:11] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	25, // 0: Interval.start:type_name -> google.protobuf.Timestamp
+	25, // 1: Interval.end:type_name -> google.protobuf.Timestamp
+	3,  // 2: Event.interval:type_name -> Interval
+	26, // 3: Event.duration:type_name -> google.protobuf.Duration
+	26, // 4: Event.relative:type_name -> google.protobuf.Duration
+	25, // 5: Event.absolute:type_name -> google.protobuf.Timestamp
+	5,  // 6: Event.attribution:type_name -> Attribution
+	26, // 7: Attribution.duration:type_name -> google.protobuf.Duration
+	22, // 8: CalendarResponse.sources:type_name -> CalendarResponse.Source
+	1,  // 9: Overlap.policy:type_name -> OverlapPolicy
+	3,  // 10: EventsRequest.interval:type_name -> Interval
+	8,  // 11: EventsRequest.location:type_name -> TextFilter
+	8,  // 12: EventsRequest.description:type_name -> TextFilter
+	9,  // 13: EventsRequest.overlap:type_name -> Overlap
+	0,  // 14: EventsRequest.attribution:type_name -> TagAttribution
+	4,  // 15: EventsResponse.events:type_name -> Event
+	3,  // 16: StatsRequest.interval:type_name -> Interval
+	8,  // 17: StatsRequest.location:type_name -> TextFilter
+	8,  // 18: StatsRequest.description:type_name -> TextFilter
+	9,  // 19: StatsRequest.overlap:type_name -> Overlap
+	0,  // 20: StatsRequest.attribution:type_name -> TagAttribution
+	26, // 21: CategoryStat.time:type_name -> google.protobuf.Duration
+	26, // 22: CategoryNode.self:type_name -> google.protobuf.Duration
+	26, // 23: CategoryNode.subtree:type_name -> google.protobuf.Duration
+	14, // 24: CategoryNode.children:type_name -> CategoryNode
+	13, // 25: StatsResponse.categories:type_name -> CategoryStat
+	26, // 26: StatsResponse.total:type_name -> google.protobuf.Duration
+	26, // 27: StatsResponse.tracked:type_name -> google.protobuf.Duration
+	26, // 28: StatsResponse.untracked:type_name -> google.protobuf.Duration
+	14, // 29: StatsResponse.tree:type_name -> CategoryNode
+	3,  // 30: BreakdownRequest.interval:type_name -> Interval
+	8,  // 31: BreakdownRequest.location:type_name -> TextFilter
+	8,  // 32: BreakdownRequest.description:type_name -> TextFilter
+	2,  // 33: BreakdownRequest.bucket_size:type_name -> BucketSize
+	9,  // 34: BreakdownRequest.overlap:type_name -> Overlap
+	0,  // 35: BreakdownRequest.attribution:type_name -> TagAttribution
+	3,  // 36: Bucket.interval:type_name -> Interval
+	26, // 37: Bucket.time:type_name -> google.protobuf.Duration
+	26, // 38: Bucket.untracked:type_name -> google.protobuf.Duration
+	17, // 39: BreakdownResponse.buckets:type_name -> Bucket
+	23, // 40: EventUpdate.tags:type_name -> EventUpdate.Tags
+	3,  // 41: EventUpdate.interval:type_name -> Interval
+	26, // 42: EventUpdate.relative:type_name -> google.protobuf.Duration
+	25, // 43: EventUpdate.absolute:type_name -> google.protobuf.Timestamp
+	19, // 44: UpdateEventsRequest.events:type_name -> EventUpdate
+	24, // 45: UpdateEventsResponse.results:type_name -> UpdateEventsResponse.Result
+	6,  // 46: CalendarService.Calendar:input_type -> CalendarRequest
+	10, // 47: CalendarService.Events:input_type -> EventsRequest
+	12, // 48: CalendarService.Stats:input_type -> StatsRequest
+	16, // 49: CalendarService.Breakdown:input_type -> BreakdownRequest
+	20, // 50: CalendarService.UpdateEvents:input_type -> UpdateEventsRequest
+	7,  // 51: CalendarService.Calendar:output_type -> CalendarResponse
+	11, // 52: CalendarService.Events:output_type -> EventsResponse
+	15, // 53: CalendarService.Stats:output_type -> StatsResponse
+	18, // 54: CalendarService.Breakdown:output_type -> BreakdownResponse
+	21, // 55: CalendarService.UpdateEvents:output_type -> UpdateEventsResponse
+	51, // [51:56] is the sub-list for method output_type
+	46, // [46:51] is the sub-list for method input_type
+	46, // [46:46] is the sub-list for extension type_name
+	46, // [46:46] is the sub-list for extension extendee
+	0,  // [0:46] is the sub-list for field type_name
 }
 
 func init() { file_v1_api_proto_init() }
@@ -571,18 +2020,24 @@ func file_v1_api_proto_init() {
 		(*Event_Absolute)(nil),
 		(*Event_None)(nil),
 	}
+	file_v1_api_proto_msgTypes[16].OneofWrappers = []any{
+		(*EventUpdate_Relative)(nil),
+		(*EventUpdate_Absolute)(nil),
+		(*EventUpdate_None)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_api_proto_rawDesc), len(file_v1_api_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_v1_api_proto_goTypes,
 		DependencyIndexes: file_v1_api_proto_depIdxs,
+		EnumInfos:         file_v1_api_proto_enumTypes,
 		MessageInfos:      file_v1_api_proto_msgTypes,
 	}.Build()
 	File_v1_api_proto = out.File
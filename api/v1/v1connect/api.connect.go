@@ -38,12 +38,23 @@ const (
 	CalendarServiceCalendarProcedure = "/CalendarService/Calendar"
 	// CalendarServiceEventsProcedure is the fully-qualified name of the CalendarService's Events RPC.
 	CalendarServiceEventsProcedure = "/CalendarService/Events"
+	// CalendarServiceStatsProcedure is the fully-qualified name of the CalendarService's Stats RPC.
+	CalendarServiceStatsProcedure = "/CalendarService/Stats"
+	// CalendarServiceBreakdownProcedure is the fully-qualified name of the CalendarService's Breakdown
+	// RPC.
+	CalendarServiceBreakdownProcedure = "/CalendarService/Breakdown"
+	// CalendarServiceUpdateEventsProcedure is the fully-qualified name of the CalendarService's
+	// UpdateEvents RPC.
+	CalendarServiceUpdateEventsProcedure = "/CalendarService/UpdateEvents"
 )
 
 // CalendarServiceClient is a client for the CalendarService service.
 type CalendarServiceClient interface {
 	Calendar(context.Context, *connect.Request[v1.CalendarRequest]) (*connect.Response[v1.CalendarResponse], error)
 	Events(context.Context, *connect.Request[v1.EventsRequest]) (*connect.Response[v1.EventsResponse], error)
+	Stats(context.Context, *connect.Request[v1.StatsRequest]) (*connect.Response[v1.StatsResponse], error)
+	Breakdown(context.Context, *connect.Request[v1.BreakdownRequest]) (*connect.Response[v1.BreakdownResponse], error)
+	UpdateEvents(context.Context, *connect.Request[v1.UpdateEventsRequest]) (*connect.Response[v1.UpdateEventsResponse], error)
 }
 
 // NewCalendarServiceClient constructs a client for the CalendarService service. By default, it uses
@@ -69,13 +80,34 @@ func NewCalendarServiceClient(httpClient connect.HTTPClient, baseURL string, opt
 			connect.WithSchema(calendarServiceMethods.ByName("Events")),
 			connect.WithClientOptions(opts...),
 		),
+		stats: connect.NewClient[v1.StatsRequest, v1.StatsResponse](
+			httpClient,
+			baseURL+CalendarServiceStatsProcedure,
+			connect.WithSchema(calendarServiceMethods.ByName("Stats")),
+			connect.WithClientOptions(opts...),
+		),
+		breakdown: connect.NewClient[v1.BreakdownRequest, v1.BreakdownResponse](
+			httpClient,
+			baseURL+CalendarServiceBreakdownProcedure,
+			connect.WithSchema(calendarServiceMethods.ByName("Breakdown")),
+			connect.WithClientOptions(opts...),
+		),
+		updateEvents: connect.NewClient[v1.UpdateEventsRequest, v1.UpdateEventsResponse](
+			httpClient,
+			baseURL+CalendarServiceUpdateEventsProcedure,
+			connect.WithSchema(calendarServiceMethods.ByName("UpdateEvents")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // calendarServiceClient implements CalendarServiceClient.
 type calendarServiceClient struct {
-	calendar *connect.Client[v1.CalendarRequest, v1.CalendarResponse]
-	events   *connect.Client[v1.EventsRequest, v1.EventsResponse]
+	calendar     *connect.Client[v1.CalendarRequest, v1.CalendarResponse]
+	events       *connect.Client[v1.EventsRequest, v1.EventsResponse]
+	stats        *connect.Client[v1.StatsRequest, v1.StatsResponse]
+	breakdown    *connect.Client[v1.BreakdownRequest, v1.BreakdownResponse]
+	updateEvents *connect.Client[v1.UpdateEventsRequest, v1.UpdateEventsResponse]
 }
 
 // Calendar calls CalendarService.Calendar.
@@ -88,10 +120,28 @@ func (c *calendarServiceClient) Events(ctx context.Context, req *connect.Request
 	return c.events.CallUnary(ctx, req)
 }
 
+// Stats calls CalendarService.Stats.
+func (c *calendarServiceClient) Stats(ctx context.Context, req *connect.Request[v1.StatsRequest]) (*connect.Response[v1.StatsResponse], error) {
+	return c.stats.CallUnary(ctx, req)
+}
+
+// Breakdown calls CalendarService.Breakdown.
+func (c *calendarServiceClient) Breakdown(ctx context.Context, req *connect.Request[v1.BreakdownRequest]) (*connect.Response[v1.BreakdownResponse], error) {
+	return c.breakdown.CallUnary(ctx, req)
+}
+
+// UpdateEvents calls CalendarService.UpdateEvents.
+func (c *calendarServiceClient) UpdateEvents(ctx context.Context, req *connect.Request[v1.UpdateEventsRequest]) (*connect.Response[v1.UpdateEventsResponse], error) {
+	return c.updateEvents.CallUnary(ctx, req)
+}
+
 // CalendarServiceHandler is an implementation of the CalendarService service.
 type CalendarServiceHandler interface {
 	Calendar(context.Context, *connect.Request[v1.CalendarRequest]) (*connect.Response[v1.CalendarResponse], error)
 	Events(context.Context, *connect.Request[v1.EventsRequest]) (*connect.Response[v1.EventsResponse], error)
+	Stats(context.Context, *connect.Request[v1.StatsRequest]) (*connect.Response[v1.StatsResponse], error)
+	Breakdown(context.Context, *connect.Request[v1.BreakdownRequest]) (*connect.Response[v1.BreakdownResponse], error)
+	UpdateEvents(context.Context, *connect.Request[v1.UpdateEventsRequest]) (*connect.Response[v1.UpdateEventsResponse], error)
 }
 
 // NewCalendarServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -113,12 +163,36 @@ func NewCalendarServiceHandler(svc CalendarServiceHandler, opts ...connect.Handl
 		connect.WithSchema(calendarServiceMethods.ByName("Events")),
 		connect.WithHandlerOptions(opts...),
 	)
+	calendarServiceStatsHandler := connect.NewUnaryHandler(
+		CalendarServiceStatsProcedure,
+		svc.Stats,
+		connect.WithSchema(calendarServiceMethods.ByName("Stats")),
+		connect.WithHandlerOptions(opts...),
+	)
+	calendarServiceBreakdownHandler := connect.NewUnaryHandler(
+		CalendarServiceBreakdownProcedure,
+		svc.Breakdown,
+		connect.WithSchema(calendarServiceMethods.ByName("Breakdown")),
+		connect.WithHandlerOptions(opts...),
+	)
+	calendarServiceUpdateEventsHandler := connect.NewUnaryHandler(
+		CalendarServiceUpdateEventsProcedure,
+		svc.UpdateEvents,
+		connect.WithSchema(calendarServiceMethods.ByName("UpdateEvents")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/CalendarService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case CalendarServiceCalendarProcedure:
 			calendarServiceCalendarHandler.ServeHTTP(w, r)
 		case CalendarServiceEventsProcedure:
 			calendarServiceEventsHandler.ServeHTTP(w, r)
+		case CalendarServiceStatsProcedure:
+			calendarServiceStatsHandler.ServeHTTP(w, r)
+		case CalendarServiceBreakdownProcedure:
+			calendarServiceBreakdownHandler.ServeHTTP(w, r)
+		case CalendarServiceUpdateEventsProcedure:
+			calendarServiceUpdateEventsHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -134,4 +208,16 @@ func (UnimplementedCalendarServiceHandler) Calendar(context.Context, *connect.Re
 
 func (UnimplementedCalendarServiceHandler) Events(context.Context, *connect.Request[v1.EventsRequest]) (*connect.Response[v1.EventsResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("CalendarService.Events is not implemented"))
+}
+
+func (UnimplementedCalendarServiceHandler) Stats(context.Context, *connect.Request[v1.StatsRequest]) (*connect.Response[v1.StatsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("CalendarService.Stats is not implemented"))
+}
+
+func (UnimplementedCalendarServiceHandler) Breakdown(context.Context, *connect.Request[v1.BreakdownRequest]) (*connect.Response[v1.BreakdownResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("CalendarService.Breakdown is not implemented"))
+}
+
+func (UnimplementedCalendarServiceHandler) UpdateEvents(context.Context, *connect.Request[v1.UpdateEventsRequest]) (*connect.Response[v1.UpdateEventsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("CalendarService.UpdateEvents is not implemented"))
 }
\ No newline at end of file
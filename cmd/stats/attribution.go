@@ -0,0 +1,120 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tagShare is the time of an event attributed to one of its tags.
+type tagShare struct {
+	tag      string
+	duration time.Duration
+}
+
+// splitTagWeight splits a tag like "work:0.7" into its name and weight, ok is
+// false for tags without a weight. Only weights between 0 and 1 are weights,
+// so tags like "room:101" keep their number.
+func splitTagWeight(tag string) (name string, weight float64, ok bool) {
+	i := strings.LastIndex(tag, ":")
+	if i < 0 {
+		return tag, 0, false
+	}
+	weight, err := strconv.ParseFloat(tag[i+1:], 64)
+	if err != nil || !(weight >= 0 && weight <= 1) {
+		return tag, 0, false
+	}
+	return tag[:i], weight, true
+}
+
+// tagName returns the tag without its weight.
+func tagName(tag string) string {
+	name, _, _ := splitTagWeight(tag)
+	return name
+}
+
+// attribute splits the duration of the event between its tags, untagged
+// events are attributed to unknownCategory. Weights are only read from the
+// tags with the weighted attribution, the other modes keep the tags as they
+// are.
+func attribute(e countedEvent, mode v1.TagAttribution) []tagShare {
+	if len(e.Tags) == 0 {
+		return []tagShare{{tag: unknownCategory, duration: e.duration}}
+	}
+
+	switch mode {
+	case v1.TagAttribution_ATTRIBUTION_EVEN:
+		weights := make([]float64, len(e.Tags))
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weighted(e, e.Tags, weights)
+	case v1.TagAttribution_ATTRIBUTION_WEIGHTED:
+		names := make([]string, len(e.Tags))
+		for i, tag := range e.Tags {
+			names[i] = tagName(tag)
+		}
+		return weighted(e, names, tagWeights(e.Tags))
+	case v1.TagAttribution_ATTRIBUTION_FULL:
+		out := make([]tagShare, len(e.Tags))
+		for i, tag := range e.Tags {
+			out[i] = tagShare{tag: tag, duration: e.duration}
+		}
+		return out
+	default:
+		return []tagShare{{tag: e.Tags[0], duration: e.duration}}
+	}
+}
+
+// tagWeights returns the weights written in the tags. Tags without a weight
+// split what the weighted tags leave of 1 evenly, if no tag has a weight they
+// are weighted equally.
+func tagWeights(tags []string) []float64 {
+	weights := make([]float64, len(tags))
+	var sum float64
+	var unweighted int
+	for i, tag := range tags {
+		_, weight, ok := splitTagWeight(tag)
+		if !ok {
+			weights[i] = -1
+			unweighted++
+			continue
+		}
+		weights[i] = weight
+		sum += weight
+	}
+	if unweighted == len(tags) {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights
+	}
+
+	rest := max(0, 1-sum) / float64(max(1, unweighted))
+	for i, weight := range weights {
+		if weight < 0 {
+			weights[i] = rest
+		}
+	}
+	return weights
+}
+
+// weighted splits the duration of the event between the tag names in
+// proportion to the weights, so the whole duration is attributed even if the
+// weights don't sum up to 1.
+func weighted(e countedEvent, names []string, weights []float64) []tagShare {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	out := make([]tagShare, 0, len(names))
+	for i, name := range names {
+		share := tagShare{tag: name}
+		if sum > 0 {
+			share.duration = time.Duration(float64(e.duration) * weights[i] / sum)
+		}
+		out = append(out, share)
+	}
+	return out
+}
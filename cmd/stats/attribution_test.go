@@ -0,0 +1,49 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/calendar"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAttribute(t *testing.T) {
+	table := []struct {
+		tags   []string
+		mode   v1.TagAttribution
+		expect []string
+	}{
+		{nil, v1.TagAttribution_ATTRIBUTION_EVEN, []string{"Unknown 1h0m0s"}},
+		// weights are only read with the weighted attribution
+		{[]string{"work:0.7", "meeting"}, v1.TagAttribution_ATTRIBUTION_FIRST, []string{"work:0.7 1h0m0s"}},
+		{[]string{"room:1", "meeting"}, v1.TagAttribution_ATTRIBUTION_EVEN, []string{"room:1 30m0s", "meeting 30m0s"}},
+		{[]string{"work", "meeting"}, v1.TagAttribution_ATTRIBUTION_EVEN, []string{"work 30m0s", "meeting 30m0s"}},
+		{[]string{"work:0.7", "meeting"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"work 42m0s", "meeting 18m0s"}},
+		// unweighted tags split the rest evenly
+		{[]string{"work:0.5", "a", "b"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"work 30m0s", "a 15m0s", "b 15m0s"}},
+		// weights are scaled to attribute the whole duration
+		{[]string{"work:0.5", "meeting:0.25"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"work 40m0s", "meeting 20m0s"}},
+		{[]string{"work:1", "meeting:1", "admin"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"work 30m0s", "meeting 30m0s", "admin 0s"}},
+		// numbers outside of [0, 1] aren't weights
+		{[]string{"room:101", "meeting:0.5"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"room:101 30m0s", "meeting 30m0s"}},
+		{[]string{"work", "meeting"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"work 30m0s", "meeting 30m0s"}},
+		// a suffix that isn't a number isn't a weight
+		{[]string{"proj:x", "meeting:0.5"}, v1.TagAttribution_ATTRIBUTION_WEIGHTED, []string{"proj:x 30m0s", "meeting 30m0s"}},
+		{[]string{"work:0.7", "meeting"}, v1.TagAttribution_ATTRIBUTION_FULL, []string{"work:0.7 1h0m0s", "meeting 1h0m0s"}},
+	}
+	for _, test := range table {
+		e := countedEvent{
+			Event:    calendar.Event{Tags: test.tags},
+			duration: time.Hour,
+		}
+		var got []string
+		for _, share := range attribute(e, test.mode) {
+			got = append(got, fmt.Sprintf("%s %v", share.tag, share.duration.Round(time.Second)))
+		}
+		if !slices.Equal(got, test.expect) {
+			t.Errorf("%v with %v: expected %v, got %v", test.tags, test.mode, test.expect, got)
+		}
+	}
+}
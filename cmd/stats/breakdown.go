@@ -0,0 +1,199 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// bucketStart returns the start of the bucket containing t in the location
+// of t.
+func bucketStart(t time.Time, size v1.BucketSize) time.Time {
+	year, month, day := t.Date()
+	switch size {
+	case v1.BucketSize_BUCKET_WEEK:
+		// time.Weekday starts on sunday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case v1.BucketSize_BUCKET_MONTH:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case v1.BucketSize_BUCKET_QUARTER:
+		quarter := (month-1)/3*3 + 1
+		return time.Date(year, quarter, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextBucket returns the start of the bucket following the one starting at
+// start.
+func nextBucket(start time.Time, size v1.BucketSize) time.Time {
+	switch size {
+	case v1.BucketSize_BUCKET_WEEK:
+		return start.AddDate(0, 0, 7)
+	case v1.BucketSize_BUCKET_MONTH:
+		return start.AddDate(0, 1, 0)
+	case v1.BucketSize_BUCKET_QUARTER:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// bucketBounds splits the interval into calendar periods in tz, the first
+// and last bucket are clipped to the interval.
+func bucketBounds(start, end time.Time, tz *time.Location, size v1.BucketSize) []time.Time {
+	if !start.Before(end) {
+		return nil
+	}
+	bounds := []time.Time{start}
+	for cur := nextBucket(bucketStart(start.In(tz), size), size); cur.Before(end); cur = nextBucket(cur, size) {
+		bounds = append(bounds, cur)
+	}
+	return append(bounds, end)
+}
+
+// breakdown computes the time per category in each bucket of the interval.
+// An event spanning multiple buckets is split between them in proportion to
+// the time it spends in each bucket, within a bucket its time is attributed to
+// its tags by the attribution mode.
+func breakdown(events []countedEvent, start, end time.Time, tz *time.Location, size v1.BucketSize, attribution v1.TagAttribution) *v1.BreakdownResponse {
+	bounds := bucketBounds(start, end, tz, size)
+	if len(bounds) == 0 {
+		return &v1.BreakdownResponse{}
+	}
+
+	tagIdxTable := map[string]int{}
+	var tags []string
+	var totals []time.Duration
+	buckets := make([][]time.Duration, len(bounds)-1)
+	tracked := make([]time.Duration, len(bounds)-1)
+
+	for _, e := range events {
+		if e.background || e.duration == 0 {
+			continue
+		}
+		shares := attribute(e, attribution)
+		tagIdxs := make([]int, len(shares))
+		for j, share := range shares {
+			tagIdx, ok := tagIdxTable[share.tag]
+			if !ok {
+				tagIdx = len(tags)
+				tagIdxTable[share.tag] = tagIdx
+				tags = append(tags, share.tag)
+				totals = append(totals, 0)
+			}
+			tagIdxs[j] = tagIdx
+		}
+
+		length := e.End.Sub(e.Start)
+		// the first bucket that ends after the event starts
+		i := sort.Search(len(buckets), func(i int) bool {
+			return bounds[i+1].After(e.Start)
+		})
+		for ; i < len(buckets); i++ {
+			bucketStart, bucketEnd := bounds[i], bounds[i+1]
+			if !e.End.After(bucketStart) {
+				break
+			}
+			// the fraction of the event within the bucket
+			fraction := 1.0
+			if length > 0 {
+				overlapStart, overlapEnd := e.Start, e.End
+				if bucketStart.After(overlapStart) {
+					overlapStart = bucketStart
+				}
+				if bucketEnd.Before(overlapEnd) {
+					overlapEnd = bucketEnd
+				}
+				fraction = float64(overlapEnd.Sub(overlapStart)) / float64(length)
+			}
+			if fraction <= 0 {
+				continue
+			}
+			for j, share := range shares {
+				d := time.Duration(float64(share.duration) * fraction)
+				tagIdx := tagIdxs[j]
+				for len(buckets[i]) <= tagIdx {
+					buckets[i] = append(buckets[i], 0)
+				}
+				buckets[i][tagIdx] += d
+				totals[tagIdx] += d
+			}
+			tracked[i] += time.Duration(float64(e.duration) * fraction)
+		}
+	}
+
+	// order the tags by their total time, so the largest categories are at
+	// the bottom of a stacked chart
+	order := make([]int, len(tags))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		diff := cmp.Compare(totals[b], totals[a])
+		if diff != 0 {
+			return diff
+		}
+		return cmp.Compare(tags[a], tags[b])
+	})
+
+	out := &v1.BreakdownResponse{
+		Tags:    make([]string, len(tags)),
+		Buckets: make([]*v1.Bucket, len(buckets)),
+	}
+	for i, tagIdx := range order {
+		out.Tags[i] = tags[tagIdx]
+	}
+	for i, bucket := range buckets {
+		times := make([]*durationpb.Duration, len(tags))
+		for j, tagIdx := range order {
+			var d time.Duration
+			if tagIdx < len(bucket) {
+				d = bucket[tagIdx]
+			}
+			times[j] = durationpb.New(d)
+		}
+		out.Buckets[i] = &v1.Bucket{
+			Interval: &v1.Interval{
+				Start: timestamppb.New(bounds[i]),
+				End:   timestamppb.New(bounds[i+1]),
+			},
+			Time:      times,
+			Untracked: durationpb.New(bounds[i+1].Sub(bounds[i]) - tracked[i]),
+		}
+	}
+	return out
+}
+
+func (s *CalendarService) Breakdown(ctx context.Context, req *connect.Request[v1.BreakdownRequest]) (*connect.Response[v1.BreakdownResponse], error) {
+	err := checkInterval(req.Msg.Interval)
+	if err != nil {
+		return nil, err
+	}
+	state := s.state.Load()
+	tz, err := time.LoadLocation(req.Msg.Timezone)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("load timezone: %w", err))
+	}
+	events, err := state.loadEvents(ctx, req.Msg.Interval, req.Msg.Timezone, req.Msg.Location, req.Msg.Description)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(breakdown(
+		resolveOverlaps(events, req.Msg.Overlap),
+		req.Msg.Interval.Start.AsTime(),
+		req.Msg.Interval.End.AsTime(),
+		tz,
+		req.Msg.BucketSize,
+		req.Msg.Attribution,
+	)), nil
+}
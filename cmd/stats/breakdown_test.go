@@ -0,0 +1,130 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/calendar"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestBucketBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	table := []struct {
+		size       v1.BucketSize
+		start, end time.Time
+		expect     []time.Time
+	}{
+		{
+			// the day of the switch to summer time is 23 hours long
+			size:   v1.BucketSize_BUCKET_DAY,
+			start:  date(time.March, 30, 12),
+			end:    date(time.April, 1, 12),
+			expect: []time.Time{date(time.March, 30, 12), date(time.March, 31, 0), date(time.April, 1, 0), date(time.April, 1, 12)},
+		},
+		{
+			// 2024-03-27 is a wednesday
+			size:   v1.BucketSize_BUCKET_WEEK,
+			start:  date(time.March, 27, 0),
+			end:    date(time.April, 10, 0),
+			expect: []time.Time{date(time.March, 27, 0), date(time.April, 1, 0), date(time.April, 8, 0), date(time.April, 10, 0)},
+		},
+		{
+			size:   v1.BucketSize_BUCKET_MONTH,
+			start:  date(time.January, 15, 0),
+			end:    date(time.March, 1, 0),
+			expect: []time.Time{date(time.January, 15, 0), date(time.February, 1, 0), date(time.March, 1, 0)},
+		},
+		{
+			size:   v1.BucketSize_BUCKET_QUARTER,
+			start:  date(time.February, 1, 0),
+			end:    date(time.August, 1, 0),
+			expect: []time.Time{date(time.February, 1, 0), date(time.April, 1, 0), date(time.July, 1, 0), date(time.August, 1, 0)},
+		},
+	}
+	for _, test := range table {
+		got := bucketBounds(test.start, test.end, berlin, test.size)
+		if !slices.EqualFunc(got, test.expect, time.Time.Equal) {
+			t.Errorf("%v: expected %v, got %v", test.size, test.expect, got)
+		}
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(day, hour int) time.Time {
+		return time.Date(2024, time.January, day, hour, 0, 0, 0, berlin)
+	}
+	event := func(start, end time.Time, duration time.Duration, tags ...string) countedEvent {
+		return countedEvent{
+			Event:    calendar.Event{Start: start, End: end, Tags: tags},
+			duration: duration,
+		}
+	}
+	events := []countedEvent{
+		event(date(1, 9), date(1, 12), 3*time.Hour, "work"),
+		// spans midnight
+		event(date(1, 23), date(2, 1), 2*time.Hour, "sleep"),
+		// an all-day event over two days counted as 8 hours per day
+		event(date(2, 0), date(4, 0), 16*time.Hour, "work"),
+		event(date(3, 10), date(3, 11), time.Hour),
+		// starts before the interval
+		event(date(0, 22), date(1, 2), 4*time.Hour, "sleep"),
+	}
+
+	res := breakdown(events, date(1, 0), date(4, 0), berlin, v1.BucketSize_BUCKET_DAY, v1.TagAttribution_ATTRIBUTION_FIRST)
+	if !slices.Equal(res.Tags, []string{"work", "sleep", "Unknown"}) {
+		t.Fatalf("unexpected tags: %v", res.Tags)
+	}
+	expect := [][]time.Duration{
+		{3 * time.Hour, 3 * time.Hour, 0},
+		{8 * time.Hour, time.Hour, 0},
+		{8 * time.Hour, 0, time.Hour},
+	}
+	if len(res.Buckets) != len(expect) {
+		t.Fatalf("expected %d buckets, got %d", len(expect), len(res.Buckets))
+	}
+	for i, bucket := range res.Buckets {
+		var tracked time.Duration
+		for j, d := range bucket.Time {
+			tracked += d.AsDuration()
+			if d.AsDuration() != expect[i][j] {
+				t.Errorf("bucket %d, %s: expected %v, got %v", i, res.Tags[j], expect[i][j], d.AsDuration())
+			}
+		}
+		if bucket.Untracked.AsDuration() != 24*time.Hour-tracked {
+			t.Errorf("bucket %d: expected %v untracked, got %v", i, 24*time.Hour-tracked, bucket.Untracked.AsDuration())
+		}
+	}
+}
+
+func TestBreakdownRequiresInterval(t *testing.T) {
+	service := NewCalendarService(&serviceState{})
+	for _, interval := range []*v1.Interval{
+		nil,
+		{Start: timestamppb.Now()},
+		{End: timestamppb.Now()},
+	} {
+		_, err := service.Breakdown(context.Background(), connect.NewRequest(&v1.BreakdownRequest{
+			Interval: interval,
+			Timezone: "UTC",
+		}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("%v: expected an invalid argument error, got %v", interval, err)
+		}
+	}
+}
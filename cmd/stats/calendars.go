@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+// checkTimeout limits how long the check command waits for a source.
+const checkTimeout = 30 * time.Second
+
+// runCalendars lists the calendars every source discovers and whether the
+// config selects them.
+func runCalendars(cfg Config) (err error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	sources, err := createSources(cfg)
+	if err != nil {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tCALENDAR\tSELECTED")
+	for _, source := range sources {
+		cals, err := source.Calendars(ctx)
+		if err != nil {
+			return fmt.Errorf("list calendars of %s: %w", source.cfg.Origin(), err)
+		}
+		for _, cal := range cals {
+			selected := "no"
+			if slices.Contains(source.cfg.Calendars, cal.Name) {
+				selected = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", source.cfg.Origin(), cal.Name, selected)
+		}
+	}
+	return w.Flush()
+}
+
+// runCheck validates the config and makes sure every source can be reached
+// and has the selected calendars, every problem is reported before failing.
+func runCheck(cfg Config) (err error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	state, err := newServiceState(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return fmt.Errorf("invalid config: port must be within [1, 65535], got %d", cfg.Port)
+	}
+	fmt.Printf("config: ok, %d sources, %d rules\n", len(state.sources), len(state.rules))
+
+	var failed int
+	for _, source := range state.sources {
+		timeout, cancel := context.WithTimeout(ctx, checkTimeout)
+		cals, err := source.selectedCalendars(timeout)
+		cancel()
+		if err != nil {
+			fmt.Printf("%s: %v\n", source.cfg.Origin(), err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: ok, %d calendars\n", source.cfg.Origin(), len(cals))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sources failed", failed, len(state.sources))
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/report"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cli holds the command line options, every struct tagged as an action is a
+// subcommand with its own options.
+type cli struct {
+	Config string `usage:"Path to the config file. If you specify ':stdin:', the program will read the config from STDIN."`
+
+	Serve     struct{}      `action:"" usage:"Host the UI and API (default)."`
+	Report    reportOptions `action:"" usage:"Print the time spent per category."`
+	Export    exportOptions `action:"" usage:"Print the categorised events as JSON lines or CSV."`
+	Calendars struct{}      `action:"" usage:"List the calendars of every source and whether they are selected."`
+	Check     struct{}      `action:"" usage:"Validate the config and test the connection to every source."`
+	Edit      editOptions   `action:"" usage:"Bulk edit events with a lua script, see docs/BULK_EDITING.md. Usage: edit [options] <script.lua>"`
+	Rules     rulesOptions  `action:"" usage:"Show which categorisation rule matches each event."`
+}
+
+type reportOptions struct {
+	Interval    string `usage:"One of the intervals of the UI: THIS_DAY, THIS_WEEK, THIS_MONTH, THIS_YEAR, LAST_3_MONTHS or LAST_6_MONTHS." choices:"THIS_DAY,THIS_WEEK,THIS_MONTH,THIS_YEAR,LAST_3_MONTHS,LAST_6_MONTHS"`
+	Start       string `usage:"Count events starting from this date (YYYY-MM-DD) instead of the interval."`
+	End         string `usage:"Count events up to this date (YYYY-MM-DD, inclusive), defaults to today with --start."`
+	Overlap     string `usage:"How overlapping events are counted: raw, innermost, shortest, tag_priority or split." choices:"raw,innermost,shortest,tag_priority,split"`
+	Priority    string `usage:"Comma separated tags, earlier tags win with the tag_priority policy."`
+	Attribution string `usage:"How the time of events with several tags is attributed: first, even, weighted or full." choices:"first,even,weighted,full"`
+	Format      string `usage:"How the report is printed: table, json, csv or markdown." choices:"table,json,csv,markdown"`
+}
+
+type exportOptions struct {
+	Interval string `usage:"One of the intervals of the UI: THIS_DAY, THIS_WEEK, THIS_MONTH, THIS_YEAR, LAST_3_MONTHS or LAST_6_MONTHS." choices:"THIS_DAY,THIS_WEEK,THIS_MONTH,THIS_YEAR,LAST_3_MONTHS,LAST_6_MONTHS"`
+	Start    string `usage:"Export events starting from this date (YYYY-MM-DD) instead of the interval."`
+	End      string `usage:"Export events up to this date (YYYY-MM-DD, inclusive), defaults to today with --start."`
+	Overlap  string `usage:"Split overlapping events into parts by a policy: raw, innermost, shortest, tag_priority or split." choices:"raw,innermost,shortest,tag_priority,split"`
+	Priority string `usage:"Comma separated tags, earlier tags win with the tag_priority policy."`
+	Format   string `usage:"How the events are printed: jsonl or csv." choices:"jsonl,csv"`
+}
+
+type editOptions struct {
+	Start  string `usage:"Load events starting from this date (YYYY-MM-DD)."`
+	End    string `usage:"Load events up to this date (YYYY-MM-DD, inclusive)."`
+	Commit bool   `usage:"Write the changes to the calendars, without this flag the changes are only printed."`
+}
+
+type rulesOptions struct {
+	Start     string `usage:"Load events starting from this date (YYYY-MM-DD)."`
+	End       string `usage:"Load events up to this date (YYYY-MM-DD, inclusive)."`
+	Unmatched bool   `usage:"Only show events that no rule matches."`
+}
+
+// newCli returns the default options, commands look at the last week unless
+// told otherwise and the report and export at the current week.
+func newCli() *cli {
+	start := time.Now().AddDate(0, 0, -7).Format(time.DateOnly)
+	end := time.Now().Format(time.DateOnly)
+
+	args := &cli{Config: "config.json5"}
+	args.Report.Interval = "THIS_WEEK"
+	args.Report.Format = "table"
+	args.Report.Overlap = "raw"
+	args.Report.Attribution = "first"
+	args.Export.Interval = "THIS_WEEK"
+	args.Export.Overlap = "raw"
+	args.Export.Format = "jsonl"
+	args.Edit.Start, args.Edit.End = start, end
+	args.Rules.Start, args.Rules.End = start, end
+	return args
+}
+
+// legacyArgs rewrites the single dash -config flag of earlier versions to
+// --config, so existing invocations keep working.
+func legacyArgs(args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			copy(out[i:], args[i:])
+			break
+		}
+		if arg == "-config" || strings.HasPrefix(arg, "-config=") {
+			arg = "-" + arg
+		}
+		out[i] = arg
+	}
+	return out
+}
+
+// parseDates parses a range of dates in the timezone, the end date is
+// inclusive so the returned end is the start of the following day.
+func parseDates(start, end string, tz *time.Location) (time.Time, time.Time, error) {
+	intvStart, err := time.ParseInLocation(time.DateOnly, start, tz)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parse start: %w", err)
+	}
+	intvEnd, err := time.ParseInLocation(time.DateOnly, end, tz)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parse end: %w", err)
+	}
+	return intvStart, intvEnd.AddDate(0, 0, 1), nil
+}
+
+// resolveInterval resolves the dates, or the interval preset if no dates are
+// given, in the timezone. The end date defaults to today.
+func resolveInterval(preset, start, end string, tz *time.Location) (time.Time, time.Time, error) {
+	if start == "" && end == "" {
+		return report.ResolvePreset(preset, time.Now().In(tz))
+	}
+	if start == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("an end date requires a start date")
+	}
+	if end == "" {
+		end = time.Now().In(tz).Format(time.DateOnly)
+	}
+	return parseDates(start, end, tz)
+}
+
+// parseEnum looks up the value of a proto enum by its name without the
+// prefix, ignoring case.
+func parseEnum(values map[string]int32, prefix, name string) (int32, error) {
+	value, ok := values[prefix+strings.ToUpper(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown value '%s'", name)
+	}
+	return value, nil
+}
+
+// parseOverlap parses an overlap policy and its comma separated tag
+// priority.
+func parseOverlap(policy, priority string) (*v1.Overlap, error) {
+	value, err := parseEnum(v1.OverlapPolicy_value, "OVERLAP_", policy)
+	if err != nil {
+		return nil, fmt.Errorf("overlap: %w", err)
+	}
+	overlap := &v1.Overlap{Policy: v1.OverlapPolicy(value)}
+	if priority != "" {
+		overlap.TagPriority = strings.Split(priority, ",")
+	}
+	return overlap, nil
+}
+
+// counting parses the overlap policy and tag attribution options.
+func (opts reportOptions) counting() (*v1.Overlap, v1.TagAttribution, error) {
+	overlap, err := parseOverlap(opts.Overlap, opts.Priority)
+	if err != nil {
+		return nil, 0, err
+	}
+	mode, err := parseEnum(v1.TagAttribution_value, "ATTRIBUTION_", opts.Attribution)
+	if err != nil {
+		return nil, 0, fmt.Errorf("attribution: %w", err)
+	}
+	return overlap, v1.TagAttribution(mode), nil
+}
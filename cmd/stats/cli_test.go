@@ -0,0 +1,71 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseDates(t *testing.T) {
+	start, end, err := parseDates("2024-03-30", "2024-03-31", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2024, time.March, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start %v", start)
+	}
+	// the end date is inclusive
+	if !end.Equal(time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected end %v", end)
+	}
+
+	_, _, err = parseDates("2024-03-30", "tomorrow", time.UTC)
+	if err == nil {
+		t.Errorf("expected an error for an invalid end")
+	}
+}
+
+func TestReportCounting(t *testing.T) {
+	overlap, attribution, err := reportOptions{
+		Overlap:     "Tag_Priority",
+		Priority:    "work,meeting",
+		Attribution: "weighted",
+	}.counting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overlap.Policy != v1.OverlapPolicy_OVERLAP_TAG_PRIORITY || !slices.Equal(overlap.TagPriority, []string{"work", "meeting"}) {
+		t.Errorf("unexpected overlap %v", overlap)
+	}
+	if attribution != v1.TagAttribution_ATTRIBUTION_WEIGHTED {
+		t.Errorf("unexpected attribution %v", attribution)
+	}
+
+	for _, opts := range []reportOptions{
+		{Overlap: "nested", Attribution: "first"},
+		{Overlap: "raw", Attribution: "half"},
+	} {
+		_, _, err := opts.counting()
+		if err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+func TestLegacyArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		expect []string
+	}{
+		{[]string{"-config", "config.json5"}, []string{"--config", "config.json5"}},
+		{[]string{"-config=config.json5", "report"}, []string{"--config=config.json5", "report"}},
+		{[]string{"--config", "config.json5", "serve"}, []string{"--config", "config.json5", "serve"}},
+		{[]string{"edit", "--", "-config"}, []string{"edit", "--", "-config"}},
+	}
+	for _, test := range tests {
+		if got := legacyArgs(test.args); !slices.Equal(got, test.expect) {
+			t.Errorf("%v: expected %v, got %v", test.args, test.expect, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"calstats/internal/bulk"
+	"calstats/internal/calendar"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func runEdit(cfg Config, opts editOptions, args []string) (err error) {
+	if len(args) != 1 {
+		return fmt.Errorf("edit: expected a single script path")
+	}
+
+	script, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read script: %w", err)
+	}
+
+	tz := time.Local
+	intvStart, intvEnd, err := parseDates(opts.Start, opts.End, tz)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	sources, err := createSources(cfg)
+	if err != nil {
+		return
+	}
+
+	var entries []bulk.Entry
+	for i, source := range sources {
+		var cals []calendar.Calendar
+		cals, err = source.selectedCalendars(ctx)
+		if err != nil {
+			return
+		}
+		for _, cal := range cals {
+			events, err := source.Events(ctx, cal, intvStart, intvEnd, tz)
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				entries = append(entries, bulk.Entry{
+					Source:      source.Source,
+					SourceIndex: i,
+					Calendar:    cal,
+					Event:       e,
+				})
+			}
+		}
+	}
+
+	changes, err := bulk.Run(string(script), entries, tz)
+	if err != nil {
+		return
+	}
+	bulk.PrintDiff(os.Stdout, changes, tz)
+
+	if !opts.Commit || len(changes) == 0 {
+		return
+	}
+	err = bulk.Commit(ctx, changes)
+	if err != nil {
+		return fmt.Errorf("commit changes: %w", err)
+	}
+	fmt.Println("changes committed")
+	return
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/export"
+	"calstats/internal/report"
+	"calstats/internal/tel"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// exportRows loads the categorised events of the interval, split by the
+// overlap policy, as rows for the exporter. The rows are sorted by start and
+// name, and events are numbered in that order so exports are reproducible.
+// Times are written in tz, whatever zone the events were stored in.
+func (st *serviceState) exportRows(ctx context.Context, start, end time.Time, tz *time.Location, overlap *v1.Overlap) ([]export.Row, error) {
+	interval := &v1.Interval{
+		Start: timestamppb.New(start),
+		End:   timestamppb.New(end),
+	}
+	events, err := st.loadEvents(ctx, interval, tz.String(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events = resolveOverlaps(events, overlap)
+	rows := make([]export.Row, len(events))
+	for i, e := range events {
+		rows[i] = export.Row{
+			EventId:     e.origin,
+			Source:      e.source.cfg.Origin(),
+			Calendar:    e.cal.Name,
+			Name:        e.Name,
+			Location:    e.Location,
+			Description: e.Description,
+			Tags:        e.Tags,
+			Start:       e.Start.In(tz),
+			End:         e.End.In(tz),
+			Duration:    e.duration,
+			AllDay:      e.AllDay,
+			Background:  e.background,
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b export.Row) int {
+		return cmp.Or(
+			a.Start.Compare(b.Start),
+			strings.Compare(a.Name, b.Name),
+			a.End.Compare(b.End),
+			strings.Compare(a.Source, b.Source),
+			strings.Compare(a.Calendar, b.Calendar),
+		)
+	})
+	// parts of an event keep sharing its id
+	ids := map[int]int{}
+	for i, row := range rows {
+		id, ok := ids[row.EventId]
+		if !ok {
+			id = len(ids)
+			ids[row.EventId] = id
+		}
+		rows[i].EventId = id
+	}
+	return rows, nil
+}
+
+// writeRows writes the rows in the format.
+func writeRows(w io.Writer, rows []export.Row, format export.Format) error {
+	out := export.NewWriter(w, format)
+	for _, row := range rows {
+		err := out.Write(row)
+		if err != nil {
+			return fmt.Errorf("write event: %w", err)
+		}
+	}
+	return out.Flush()
+}
+
+// runExport prints the events in the interval as JSON lines or CSV.
+func runExport(cfg Config, opts exportOptions) (err error) {
+	tz := time.Local
+	start, end, err := resolveInterval(opts.Interval, opts.Start, opts.End, tz)
+	if err != nil {
+		return
+	}
+	overlap, err := parseOverlap(opts.Overlap, opts.Priority)
+	if err != nil {
+		return
+	}
+	format, err := export.ParseFormat(opts.Format)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	state, err := newServiceState(cfg)
+	if err != nil {
+		return
+	}
+	rows, err := state.exportRows(ctx, start, end, tz, overlap)
+	if err != nil {
+		return
+	}
+	return writeRows(os.Stdout, rows, format)
+}
+
+// downloadQuery holds the query parameters shared by the downloads, they
+// match the options of the export command.
+type downloadQuery struct {
+	start, end time.Time
+	tz         *time.Location
+	overlap    *v1.Overlap
+}
+
+// interval returns the interval of the query for loading events.
+func (q downloadQuery) interval() *v1.Interval {
+	return &v1.Interval{
+		Start: timestamppb.New(q.start),
+		End:   timestamppb.New(q.end),
+	}
+}
+
+// parseDownloadQuery parses the "timezone", "interval", "start", "end",
+// "overlap" and "priority" parameters. Without an interval or dates, the
+// interval is resolved by fallback at the current time.
+func parseDownloadQuery(query url.Values, fallback func(now time.Time) (time.Time, time.Time)) (q downloadQuery, err error) {
+	timezone := query.Get("timezone")
+	if timezone == "" {
+		timezone = "Local"
+	}
+	q.tz, err = time.LoadLocation(timezone)
+	if err != nil {
+		return q, fmt.Errorf("load timezone: %w", err)
+	}
+
+	preset, start, end := query.Get("interval"), query.Get("start"), query.Get("end")
+	if preset == "" && start == "" && end == "" {
+		q.start, q.end = fallback(time.Now().In(q.tz))
+	} else {
+		q.start, q.end, err = resolveInterval(preset, start, end, q.tz)
+		if err != nil {
+			return
+		}
+	}
+
+	policy := query.Get("overlap")
+	if policy == "" {
+		policy = "raw"
+	}
+	q.overlap, err = parseOverlap(policy, query.Get("priority"))
+	return
+}
+
+// ServeExport serves the events as a file download. It takes the options of
+// the export command as query parameters, with the timezone of the dates in
+// "timezone":
+//
+//	GET /export?format=csv&interval=THIS_MONTH&overlap=innermost&timezone=Europe/Berlin
+//	GET /export?format=jsonl&start=2024-01-01&end=2024-01-31
+func (s *CalendarService) ServeExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q, err := parseDownloadQuery(query, func(now time.Time) (time.Time, time.Time) {
+		start, end, _ := report.ResolvePreset("THIS_WEEK", now)
+		return start, end
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := query.Get("format")
+	if name == "" {
+		name = string(export.FormatJSONL)
+	}
+	format, err := export.ParseFormat(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.state.Load().exportRows(r.Context(), q.start, q.end, q.tz, q.overlap)
+	if err != nil {
+		tel.Log.Error("export", "load events", "err", err)
+		http.Error(w, fmt.Sprintf("load events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		`attachment; filename="calstats-%s-%s.%s"`,
+		q.start.Format(time.DateOnly),
+		q.end.Add(-time.Nanosecond).Format(time.DateOnly),
+		format,
+	))
+	// the status is sent with the first row, so errors while writing can
+	// only be logged
+	err = writeRows(w, rows, format)
+	if err != nil {
+		tel.Log.Error("export", "write events", "err", err)
+	}
+}
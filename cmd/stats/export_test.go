@@ -0,0 +1,98 @@
+package main
+
+import (
+	"calstats/internal/calendar"
+	"calstats/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeExport(t *testing.T) {
+	state, err := newServiceState(Config{
+		Sources: []config.Source{{
+			Path:      "../../internal/calendar/testdata/exdate/nextcloud.ics",
+			Calendars: []string{"nextcloud"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewCalendarService(state)
+
+	// the standup repeats on monday, wednesday and friday, the wednesday
+	// is excluded
+	req := httptest.NewRequest(http.MethodGet, "/export?format=csv&start=2024-01-01&end=2024-01-07&timezone=Europe/Berlin", nil)
+	rec := httptest.NewRecorder()
+	service.ServeExport(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="calstats-2024-01-01-2024-01-07.csv"` {
+		t.Errorf("unexpected content disposition %s", got)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	expect := []string{
+		"event_id,source,calendar,name,location,description,tags,start,end,duration_seconds,all_day,background",
+		"0,../../internal/calendar/testdata/exdate/nextcloud.ics,nextcloud,Standup,,,work,2024-01-01T09:00:00+01:00,2024-01-01T09:15:00+01:00,900,false,false",
+		"1,../../internal/calendar/testdata/exdate/nextcloud.ics,nextcloud,Standup,,,work,2024-01-05T09:00:00+01:00,2024-01-05T09:15:00+01:00,900,false,false",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expect, "\n"), rec.Body)
+	}
+
+	for _, query := range []string{
+		"format=parquet",
+		"interval=YESTERDAY",
+		"end=2024-01-07",
+		"overlap=nested",
+		"timezone=Mars/Olympus",
+	} {
+		rec := httptest.NewRecorder()
+		service.ServeExport(rec, httptest.NewRequest(http.MethodGet, "/export?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestExportRowsOrder(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, time.January, 1, hour, 0, 0, 0, time.UTC)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Source{Calendars: []string{"work"}}
+	state := &serviceState{sources: []sourceConfig{
+		{Source: fakeSource{events: []calendar.Event{
+			{Id: 1, Name: "Review", Start: at(11), End: at(12)},
+			{Id: 2, Name: "Standup", Start: at(9), End: at(10)},
+		}}, cfg: cfg},
+		{Source: fakeSource{events: []calendar.Event{
+			{Id: 3, Name: "Planning", Start: at(9).In(tokyo), End: at(10).In(tokyo)},
+		}}, cfg: cfg},
+	}}
+
+	rows, err := state.exportRows(context.Background(), at(0), at(24), time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i, row := range rows {
+		if row.EventId != i {
+			t.Errorf("%s: expected event id %d, got %d", row.Name, i, row.EventId)
+		}
+		got = append(got, row.Name)
+		if row.Start.Location() != time.UTC || row.End.Location() != time.UTC {
+			t.Errorf("%s: expected times in UTC, got %v to %v", row.Name, row.Start, row.End)
+		}
+	}
+	if strings.Join(got, ",") != "Planning,Standup,Review" {
+		t.Errorf("expected rows by start and name, got %v", got)
+	}
+}
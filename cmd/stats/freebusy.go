@@ -0,0 +1,99 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/freebusy"
+	"calstats/internal/tel"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxRequestSize limits the size of VFREEBUSY requests.
+const maxRequestSize = 1 << 20
+
+// busyPeriods loads the events of the interval and merges them into the busy
+// periods of the free/busy rules. Untagged events have the unknown category,
+// background events are free.
+func (st *serviceState) busyPeriods(ctx context.Context, start, end time.Time, tz *time.Location) ([]freebusy.Period, error) {
+	interval := &v1.Interval{
+		Start: timestamppb.New(start),
+		End:   timestamppb.New(end),
+	}
+	events, err := st.loadEvents(ctx, interval, tz.String(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]freebusy.Period, 0, len(events))
+	for _, e := range events {
+		if e.background {
+			continue
+		}
+		tags := normalizeTags(e.Tags)
+		if len(tags) == 0 {
+			tags = []string{unknownCategory}
+		}
+		periods = append(periods, freebusy.Period{Start: e.Start, End: e.End, Type: st.freeBusy.Type(tags)})
+	}
+	return freebusy.Merge(periods, start, end), nil
+}
+
+// ServeFreeBusy publishes when the user is busy without the details of the
+// events. GET takes the "timezone", "interval", "start" and "end" parameters
+// of the feed and covers the same three months before and after today by
+// default:
+//
+//	GET /freebusy?interval=THIS_MONTH
+//
+// POST answers a VFREEBUSY request with a reply for its DTSTART and DTEND:
+//
+//	POST /freebusy
+//	Content-Type: text/calendar
+func (s *CalendarService) ServeFreeBusy(w http.ResponseWriter, r *http.Request) {
+	var (
+		query = r.URL.Query()
+		req   freebusy.Request
+	)
+	q, err := parseDownloadQuery(query, feedInterval)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodPost {
+		cal, err := ical.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("decode request: %v", err), http.StatusBadRequest)
+			return
+		}
+		req, err = freebusy.ParseRequest(cal)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.start, q.end = req.Start, req.End
+	}
+
+	periods, err := s.state.Load().busyPeriods(r.Context(), q.start, q.end, q.tz)
+	if err != nil {
+		tel.Log.Error("freebusy", "load events", "err", err)
+		http.Error(w, fmt.Sprintf("load events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var cal *ical.Calendar
+	if r.Method == http.MethodPost {
+		cal = freebusy.Reply(req, periods, time.Now())
+	} else {
+		cal = freebusy.Publish(periods, q.start, q.end, time.Now())
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	err = ical.NewEncoder(w).Encode(cal)
+	if err != nil {
+		tel.Log.Error("freebusy", "write calendar", "err", err)
+	}
+}
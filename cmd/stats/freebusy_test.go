@@ -0,0 +1,74 @@
+package main
+
+import (
+	"calstats/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeFreeBusy(t *testing.T) {
+	state, err := newServiceState(Config{
+		Sources: []config.Source{{
+			Path:      "../../internal/calendar/testdata/exdate/nextcloud.ics",
+			Calendars: []string{"nextcloud"},
+		}},
+		FreeBusy: config.FreeBusy{Rules: []config.FreeBusyRule{{Tag: "work", Type: "busy-tentative"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewCalendarService(state)
+
+	// the standup repeats on monday, wednesday and friday, the wednesday
+	// is excluded
+	rec := httptest.NewRecorder()
+	service.ServeFreeBusy(rec, httptest.NewRequest(http.MethodGet, "/freebusy?start=2024-01-01&end=2024-01-07&timezone=Europe/Berlin", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		"METHOD:PUBLISH",
+		"FREEBUSY;FBTYPE=BUSY-TENTATIVE:20240101T080000Z/20240101T081500Z",
+		"FREEBUSY;FBTYPE=BUSY-TENTATIVE:20240105T080000Z/20240105T081500Z",
+	} {
+		if !strings.Contains(body, line+"\r\n") {
+			t.Errorf("expected %s in\n%s", line, body)
+		}
+	}
+	if strings.Count(body, "FREEBUSY;") != 2 || strings.Contains(body, "Standup") {
+		t.Errorf("expected only the two standups without details, got\n%s", body)
+	}
+
+	request := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//test//EN",
+		"METHOD:REQUEST",
+		"BEGIN:VFREEBUSY",
+		"UID:request-1",
+		"DTSTAMP:20240101T000000Z",
+		"DTSTART:20240105T000000Z",
+		"DTEND:20240106T000000Z",
+		"END:VFREEBUSY",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	rec = httptest.NewRecorder()
+	service.ServeFreeBusy(rec, httptest.NewRequest(http.MethodPost, "/freebusy", strings.NewReader(request)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body = rec.Body.String()
+	if !strings.Contains(body, "METHOD:REPLY\r\n") || strings.Count(body, "FREEBUSY;") != 1 {
+		t.Errorf("expected a reply with the friday standup, got\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	service.ServeFreeBusy(rec, httptest.NewRequest(http.MethodPost, "/freebusy", strings.NewReader("not a calendar")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+}
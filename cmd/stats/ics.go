@@ -0,0 +1,148 @@
+package main
+
+import (
+	"calstats/internal/tel"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// feedInterval is the interval of the iCalendar feed when the request
+// doesn't specify one, the three months before and after today.
+func feedInterval(now time.Time) (time.Time, time.Time) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	return today.AddDate(0, -3, 0), today.AddDate(0, 3, 0)
+}
+
+// normalizeTags returns the names of the tags without their weights and
+// surrounding space, leaving out empty and repeated tags.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		name := strings.TrimSpace(tagName(tag))
+		if name != "" && !slices.Contains(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// feedUid returns a UID that stays the same between requests. Events are
+// identified by their source, calendar and instance id, parts of an event
+// split by an overlap policy are numbered.
+func feedUid(e countedEvent, part int) string {
+	h := fnv.New64a()
+	io.WriteString(h, e.source.cfg.Origin())
+	h.Write([]byte{0})
+	io.WriteString(h, e.cal.Id)
+	uid := fmt.Sprintf("%016x-%016x", h.Sum64(), e.Id)
+	if part > 0 {
+		uid += fmt.Sprintf("-%d", part)
+	}
+	return uid + "@calstats"
+}
+
+// isMidnight is true if t is the start of a day in its location.
+func isMidnight(t time.Time) bool {
+	hour, min, sec := t.Clock()
+	return hour == 0 && min == 0 && sec == 0 && t.Nanosecond() == 0
+}
+
+// feedCalendar converts the events into a calendar, with their tags as
+// CATEGORIES. Times are written in UTC so the feed needs no VTIMEZONE,
+// all-day events keep their dates unless an overlap policy cut them.
+func feedCalendar(events []countedEvent, now time.Time) *ical.Calendar {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, "-//calstats//calstats//EN")
+	name := ical.NewProp("X-WR-CALNAME")
+	name.Value = "calstats"
+	cal.Props.Set(name)
+
+	parts := map[int]int{}
+	for _, e := range events {
+		part := parts[e.origin]
+		parts[e.origin]++
+
+		event := ical.NewEvent()
+		event.Props.SetText(ical.PropUID, feedUid(e, part))
+		event.Props.SetDateTime(ical.PropDateTimeStamp, now.UTC())
+		event.Props.SetText(ical.PropSummary, e.Name)
+		if e.Location != "" {
+			event.Props.SetText(ical.PropLocation, e.Location)
+		}
+		if e.Description != "" {
+			event.Props.SetText(ical.PropDescription, e.Description)
+		}
+		if tags := normalizeTags(e.Tags); len(tags) > 0 {
+			prop := ical.NewProp(ical.PropCategories)
+			prop.SetTextList(tags)
+			event.Props.Set(prop)
+		}
+
+		if e.AllDay && isMidnight(e.Start) && isMidnight(e.End) {
+			event.Props.SetDate(ical.PropDateTimeStart, e.Start)
+			event.Props.SetDate(ical.PropDateTimeEnd, e.End)
+		} else {
+			event.Props.SetDateTime(ical.PropDateTimeStart, e.Start.UTC())
+			event.Props.SetDateTime(ical.PropDateTimeEnd, e.End.UTC())
+		}
+		// background events don't consume time, so they don't make anyone
+		// busy either
+		if e.background {
+			event.Props.SetText(ical.PropTransparency, "TRANSPARENT")
+		}
+		cal.Children = append(cal.Children, event.Component)
+	}
+	return cal
+}
+
+// writeCalendar encodes the calendar. go-ical refuses to encode calendars
+// without components, an empty feed is written by hand instead.
+func writeCalendar(w io.Writer, cal *ical.Calendar) error {
+	if len(cal.Children) > 0 {
+		return ical.NewEncoder(w).Encode(cal)
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	for _, name := range []string{ical.PropVersion, ical.PropProductID, "X-WR-CALNAME"} {
+		fmt.Fprintf(&b, "%s:%s\r\n", name, cal.Props.Get(name).Value)
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeFeed serves the categorised events of all sources as one read-only
+// iCalendar feed to subscribe to. It takes the same query parameters as the
+// export download, by default the feed covers the three months before and
+// after today:
+//
+//	GET /calendar.ics?overlap=innermost
+func (s *CalendarService) ServeFeed(w http.ResponseWriter, r *http.Request) {
+	q, err := parseDownloadQuery(r.URL.Query(), feedInterval)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.state.Load().loadEvents(r.Context(), q.interval(), q.tz.String(), nil, nil)
+	if err != nil {
+		tel.Log.Error("feed", "load events", "err", err)
+		http.Error(w, fmt.Sprintf("load events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	err = writeCalendar(w, feedCalendar(resolveOverlaps(events, q.overlap), time.Now()))
+	if err != nil {
+		tel.Log.Error("feed", "write calendar", "err", err)
+	}
+}
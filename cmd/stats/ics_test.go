@@ -0,0 +1,107 @@
+package main
+
+import (
+	"calstats/internal/calendar"
+	"calstats/internal/config"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+func TestFeedCalendar(t *testing.T) {
+	source := sourceConfig{cfg: config.Source{Path: "work.ics"}}
+	cal := calendar.Calendar{Id: "work.ics", Name: "Work"}
+	date := func(day, hour int) time.Time {
+		return time.Date(2024, time.January, day, hour, 0, 0, 0, time.FixedZone("", 3600))
+	}
+	event := func(origin int, id uint64, start, end time.Time, tags ...string) countedEvent {
+		return countedEvent{
+			Event:  calendar.Event{Id: id, Name: "event", Start: start, End: end, Tags: tags},
+			origin: origin,
+			source: source,
+			cal:    cal,
+		}
+	}
+	holiday := event(2, 3, date(2, 0), date(3, 0), "holiday")
+	holiday.AllDay = true
+	holiday.background = true
+
+	events := []countedEvent{
+		event(0, 1, date(1, 9), date(1, 10), "work/review:0.5", " meeting", "work/review"),
+		// the parts of an event split by an overlap policy
+		event(1, 2, date(1, 10), date(1, 11)),
+		event(1, 2, date(1, 12), date(1, 13)),
+		holiday,
+	}
+
+	var b strings.Builder
+	err := writeCalendar(&b, feedCalendar(events, date(1, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ical.NewDecoder(strings.NewReader(b.String())).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decoded.Events()
+	if len(got) != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), len(got))
+	}
+
+	var uids []string
+	for i, e := range got {
+		uid, _ := e.Props.Text(ical.PropUID)
+		if slices.Contains(uids, uid) {
+			t.Errorf("event %d: duplicate uid %s", i, uid)
+		}
+		uids = append(uids, uid)
+
+		start, err := e.DateTimeStart(time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !start.Equal(events[i].Start) && !(i == 3 && start.Equal(time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC))) {
+			t.Errorf("event %d: expected start %v, got %v", i, events[i].Start, start)
+		}
+	}
+
+	tags, _ := got[0].Props.Get(ical.PropCategories).TextList()
+	if !slices.Equal(tags, []string{"work/review", "meeting"}) {
+		t.Errorf("expected normalised categories, got %v", tags)
+	}
+	if got[1].Props.Get(ical.PropCategories) != nil {
+		t.Errorf("expected no categories for an untagged event")
+	}
+	if got[3].Props.Get(ical.PropDateTimeStart).ValueType() != ical.ValueDate {
+		t.Errorf("expected the all-day event to start on a date")
+	}
+	if transp, _ := got[3].Props.Text(ical.PropTransparency); transp != "TRANSPARENT" {
+		t.Errorf("expected the background event to be transparent, got '%s'", transp)
+	}
+
+	// uids stay the same between requests
+	var again strings.Builder
+	err = writeCalendar(&again, feedCalendar(events, date(5, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uid := range uids {
+		if !strings.Contains(again.String(), "UID:"+uid) {
+			t.Errorf("uid %s changed", uid)
+		}
+	}
+}
+
+func TestWriteEmptyCalendar(t *testing.T) {
+	var b strings.Builder
+	err := writeCalendar(&b, feedCalendar(nil, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "BEGIN:VCALENDAR\r\n") || !strings.Contains(b.String(), "VERSION:2.0\r\n") {
+		t.Errorf("unexpected empty calendar:\n%s", b.String())
+	}
+}
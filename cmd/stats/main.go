@@ -4,10 +4,11 @@ import (
 	"calstats/api/v1/v1connect"
 	"calstats/internal/calendar"
 	"calstats/internal/config"
+	"calstats/internal/freebusy"
+	"calstats/internal/rules"
 	"calstats/internal/tel"
 	"context"
 	"embed"
-	"flag"
 	"fmt"
 	"io"
 	"io/fs"
@@ -18,6 +19,8 @@ import (
 
 	"connectrpc.com/connect"
 	connectcors "connectrpc.com/cors"
+	"github.com/hujun-open/myflags/v2"
+	"github.com/hujun-open/shouchan/v2"
 	"github.com/rs/cors"
 	"github.com/titanous/json5"
 	"golang.org/x/net/http2"
@@ -30,37 +33,67 @@ var ui embed.FS
 type Config struct {
 	Port    int             `json:"port"`    // The port to host the UI and API on.
 	Sources []config.Source `json:"sources"` // Define calendar sources.
-}
+	Rules   []config.Rule   `json:"rules"`   // Assign tags to events by their name, location, description, calendar or source.
 
-const description = `Visualize how your time is spent.`
+	CategorySeparator *string         `json:"category_separator"` // Separates the levels of hierarchical categories like "work/meetings", defaults to "/". Set it to "" to keep categories flat.
+	FreeBusy          config.FreeBusy `json:"free_busy"`          // Decide which tags make you busy in the free/busy endpoints.
+}
 
-func init() {
-	flag.Usage = func() {
-		fmt.Fprintf(
-			flag.CommandLine.Output(),
-			"%s\n\nUsage: %s [options]\n\nOptions:\n",
-			description,
-			os.Args[0],
-		)
-		flag.PrintDefaults()
+// separator returns the configured category separator.
+func (cfg Config) separator() string {
+	if cfg.CategorySeparator == nil {
+		return "/"
 	}
+	return *cfg.CategorySeparator
 }
 
+const description = `Visualize how your time is spent.`
+
 func main() {
-	configpath := flag.String(
-		"config",
-		"config.json5",
-		"Path to the config file. If you specify `:stdin:`, the program will read the config from STDIN.",
+	args := newCli()
+	conf, err := shouchan.NewSConf(
+		args, "calstats", description,
+		shouchan.WithFillOptions[cli]([]myflags.FillerOption{
+			// serve when no command is given
+			myflags.WithRootMethod(myflags.DefRunMethod),
+		}),
 	)
-	flag.Parse()
+	if err != nil {
+		tel.Log.Error("main", fmt.Errorf("create flags: %w", err).Error())
+		os.Exit(1)
+	}
+	cmd, _, err := conf.Read(legacyArgs(os.Args[1:]))
+	if err != nil {
+		os.Exit(1)
+	}
+	if myflags.IsOwnAction(cmd, "", "", false) {
+		return
+	}
 
-	cfg, err := parseConfig(*configpath)
+	cfg, err := parseConfig(args.Config)
 	if err != nil {
 		tel.Log.Error("main", fmt.Errorf("parse config: %w", err).Error())
 		os.Exit(1)
 	}
 
-	err = run(cfg)
+	switch cmd {
+	case cmd.Root(), conf.Filler.GetChildCommand("/serve"):
+		err = run(cfg, args.Config)
+	case conf.Filler.GetChildCommand("/report"):
+		err = runReport(cfg, args.Report)
+	case conf.Filler.GetChildCommand("/export"):
+		err = runExport(cfg, args.Export)
+	case conf.Filler.GetChildCommand("/calendars"):
+		err = runCalendars(cfg)
+	case conf.Filler.GetChildCommand("/check"):
+		err = runCheck(cfg)
+	case conf.Filler.GetChildCommand("/edit"):
+		err = runEdit(cfg, args.Edit, cmd.Flags().Args())
+	case conf.Filler.GetChildCommand("/rules"):
+		err = runRules(cfg, args.Rules)
+	default:
+		err = fmt.Errorf("unknown command '%s'", cmd.Name())
+	}
 	if err != nil {
 		tel.Log.Error("main", err.Error())
 		os.Exit(1)
@@ -78,6 +111,7 @@ func parseConfig(path string) (config Config, err error) {
 		if err != nil {
 			return
 		}
+		defer file.Close()
 	}
 
 	contents, err = io.ReadAll(file)
@@ -91,7 +125,46 @@ func parseConfig(path string) (config Config, err error) {
 	return
 }
 
-func run(cfg Config) (err error) {
+func createSources(cfg Config) (sources []sourceConfig, err error) {
+	sources = make([]sourceConfig, len(cfg.Sources))
+	for i, src := range cfg.Sources {
+		var source calendar.Source
+		source, err = src.Source()
+		if err != nil {
+			err = fmt.Errorf("create calendar: %w", err)
+			return
+		}
+		sources[i] = sourceConfig{
+			cfg:    src,
+			Source: source,
+		}
+	}
+	return
+}
+
+// newServiceState creates the sources and compiles the rules of the config.
+func newServiceState(cfg Config) (*serviceState, error) {
+	sources, err := createSources(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ruleset, err := rules.Compile(cfg.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("compile rules: %w", err)
+	}
+	freeBusy, err := freebusy.NewClassifier(cfg.FreeBusy, cfg.separator())
+	if err != nil {
+		return nil, err
+	}
+	return &serviceState{
+		sources:   sources,
+		rules:     ruleset,
+		separator: cfg.separator(),
+		freeBusy:  freeBusy,
+	}, nil
+}
+
+func run(cfg Config, configpath string) (err error) {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
@@ -104,24 +177,34 @@ func run(cfg Config) (err error) {
 	}
 	mux.Handle("/", http.FileServerFS(buildFs))
 
-	// initialize sources
-	sources := make([]sourceConfig, len(cfg.Sources))
-	for i, src := range cfg.Sources {
-		var source calendar.Source
-		source, err = src.Server.Source()
+	state, err := newServiceState(cfg)
+	if err != nil {
+		return
+	}
+	service := NewCalendarService(state)
+
+	// reload the sources and rules when the config changes, the port can
+	// only be changed by restarting
+	if configpath != ":stdin:" {
+		err = watchConfig(ctx, configpath, func(next Config) error {
+			state, err := newServiceState(next)
+			if err != nil {
+				return err
+			}
+			if next.Port != cfg.Port {
+				tel.Log.Warn("main", "port changes require a restart", "port", cfg.Port)
+			}
+			service.Reload(state)
+			return nil
+		})
 		if err != nil {
-			err = fmt.Errorf("create calendar: %w", err)
-			return
-		}
-		sources[i] = sourceConfig{
-			cfg:    src,
-			Source: source,
+			return fmt.Errorf("watch config: %w", err)
 		}
 	}
 
 	// setup rpc
 	handle, handler := v1connect.NewCalendarServiceHandler(
-		NewCalendarService(sources),
+		service,
 		connect.WithInterceptors(
 			connect.UnaryInterceptorFunc(tel.LogErrorsInterceptor),
 		),
@@ -133,6 +216,10 @@ func run(cfg Config) (err error) {
 		ExposedHeaders: connectcors.ExposedHeaders(),
 	})
 	mux.Handle(handle, withCors.Handler(handler))
+	mux.Handle("GET /export", withCors.Handler(http.HandlerFunc(service.ServeExport)))
+	mux.Handle("GET /calendar.ics", withCors.Handler(http.HandlerFunc(service.ServeFeed)))
+	mux.Handle("GET /freebusy", withCors.Handler(http.HandlerFunc(service.ServeFreeBusy)))
+	mux.Handle("POST /freebusy", withCors.Handler(http.HandlerFunc(service.ServeFreeBusy)))
 
 	// run servers
 	tel.Log.Info("main", "listening on...", "port", cfg.Port)
@@ -0,0 +1,167 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/calendar"
+	"slices"
+	"time"
+)
+
+// resolveOverlaps applies the overlap policy to the events and returns the
+// parts of them that count towards the statistics. Parts keep the origin of
+// their event, their duration is scaled down to the time they cover.
+// Background events and events without length are passed through.
+func resolveOverlaps(events []countedEvent, overlap *v1.Overlap) []countedEvent {
+	policy := overlap.GetPolicy()
+	if policy == v1.OverlapPolicy_OVERLAP_RAW {
+		return events
+	}
+
+	var out, timed []countedEvent
+	for _, e := range events {
+		if e.background || !e.End.After(e.Start) {
+			out = append(out, e)
+			continue
+		}
+		timed = append(timed, e)
+	}
+	// sweeping expects events ordered by start, longer events first
+	slices.SortStableFunc(timed, func(a, b countedEvent) int {
+		diff := a.Start.Compare(b.Start)
+		if diff != 0 {
+			return diff
+		}
+		return b.End.Compare(a.End)
+	})
+
+	if policy == v1.OverlapPolicy_OVERLAP_INNERMOST {
+		return append(out, innermostParts(timed)...)
+	}
+	return append(out, sweepParts(timed, overlap)...)
+}
+
+// part returns the part of e between start and end, which counts share of
+// the time between them.
+func part(e countedEvent, start, end time.Time, share float64) countedEvent {
+	length := e.End.Sub(e.Start)
+	p := e
+	p.Start = start
+	p.End = end
+	p.duration = time.Duration(float64(e.duration) * float64(end.Sub(start)) * share / float64(length))
+	return p
+}
+
+// innermostParts resolves overlaps with DeoverlapEvents.
+func innermostParts(events []countedEvent) []countedEvent {
+	// the ids are replaced by indices so the parts can be traced back to
+	// their event, ids aren't unique across calendars
+	list := make([]calendar.Event, len(events))
+	for i, e := range events {
+		list[i] = calendar.Event{
+			Id:    uint64(i),
+			Name:  e.Name,
+			Tags:  e.Tags,
+			Start: e.Start,
+			End:   e.End,
+		}
+	}
+	DeoverlapEvents(&list)
+
+	out := make([]countedEvent, 0, len(list))
+	for _, p := range list {
+		if !p.End.After(p.Start) {
+			continue
+		}
+		out = append(out, part(events[p.Id], p.Start, p.End, 1))
+	}
+	return out
+}
+
+// sweepParts splits the time into segments between the bounds of all events
+// and hands each segment to the events that win it under the policy.
+func sweepParts(events []countedEvent, overlap *v1.Overlap) []countedEvent {
+	bounds := make([]time.Time, 0, len(events)*2)
+	for _, e := range events {
+		bounds = append(bounds, e.Start, e.End)
+	}
+	slices.SortFunc(bounds, time.Time.Compare)
+	bounds = slices.CompactFunc(bounds, time.Time.Equal)
+
+	rank := func(e countedEvent) int {
+		if len(e.Tags) == 0 {
+			return len(overlap.GetTagPriority()) + 1
+		}
+		i := slices.Index(overlap.GetTagPriority(), tagName(e.Tags[0]))
+		if i < 0 {
+			return len(overlap.GetTagPriority())
+		}
+		return i
+	}
+	// wins reports whether a takes the time of an overlap with b
+	wins := func(a, b countedEvent) bool {
+		if overlap.GetPolicy() == v1.OverlapPolicy_OVERLAP_TAG_PRIORITY {
+			ra, rb := rank(a), rank(b)
+			if ra != rb {
+				return ra < rb
+			}
+		}
+		la, lb := a.End.Sub(a.Start), b.End.Sub(b.Start)
+		if overlap.GetPolicy() == v1.OverlapPolicy_OVERLAP_SHORTEST && la != lb {
+			return la < lb
+		}
+		// ties are resolved like OVERLAP_INNERMOST
+		if !a.Start.Equal(b.Start) {
+			return a.Start.After(b.Start)
+		}
+		return la < lb
+	}
+
+	var out []countedEvent
+	// the index in out of the last part of each event
+	last := make([]int, len(events))
+	for i := range last {
+		last[i] = -1
+	}
+	addPart := func(i int, start, end time.Time, share float64) {
+		p := part(events[i], start, end, share)
+		if j := last[i]; j >= 0 && out[j].End.Equal(start) {
+			out[j].End = end
+			out[j].duration += p.duration
+			return
+		}
+		last[i] = len(out)
+		out = append(out, p)
+	}
+
+	var active []int
+	next := 0
+	for k := 0; k < len(bounds)-1; k++ {
+		start, end := bounds[k], bounds[k+1]
+		active = slices.DeleteFunc(active, func(i int) bool {
+			return !events[i].End.After(start)
+		})
+		for next < len(events) && !events[next].Start.After(start) {
+			active = append(active, next)
+			next++
+		}
+		if len(active) == 0 {
+			continue
+		}
+
+		if overlap.GetPolicy() == v1.OverlapPolicy_OVERLAP_SPLIT {
+			share := 1 / float64(len(active))
+			for _, i := range active {
+				addPart(i, start, end, share)
+			}
+			continue
+		}
+		winner := active[0]
+		for _, i := range active[1:] {
+			if wins(events[i], events[winner]) {
+				winner = i
+			}
+		}
+		addPart(winner, start, end, 1)
+	}
+	return out
+}
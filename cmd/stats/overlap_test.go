@@ -0,0 +1,107 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/calendar"
+	"testing"
+	"time"
+)
+
+func TestResolveOverlaps(t *testing.T) {
+	datetime := func(hour, minute int) time.Time {
+		return time.Date(2000, time.January, 1, hour, minute, 0, 0, time.UTC)
+	}
+	event := func(origin int, tag string, start, end time.Time, duration time.Duration) countedEvent {
+		return countedEvent{
+			Event:    calendar.Event{Name: tag, Tags: []string{tag}, Start: start, End: end},
+			origin:   origin,
+			duration: duration,
+		}
+	}
+	overlapping := []countedEvent{
+		event(0, "work", datetime(9, 0), datetime(12, 0), 3*time.Hour),
+		event(1, "meeting", datetime(10, 0), datetime(11, 0), time.Hour),
+		event(2, "sport", datetime(10, 30), datetime(13, 0), 150*time.Minute),
+	}
+	// the work event is an all-day like event counting half of its length
+	nested := []countedEvent{
+		event(0, "work", datetime(9, 0), datetime(12, 0), 90*time.Minute),
+		event(1, "meeting", datetime(10, 0), datetime(11, 0), time.Hour),
+	}
+
+	table := []struct {
+		name    string
+		events  []countedEvent
+		overlap *v1.Overlap
+		expect  map[string]time.Duration
+		parts   int
+	}{
+		{
+			name:    "raw",
+			events:  overlapping,
+			overlap: nil,
+			expect:  map[string]time.Duration{"work": 3 * time.Hour, "meeting": time.Hour, "sport": 150 * time.Minute},
+			parts:   3,
+		},
+		{
+			name:    "innermost",
+			events:  nested,
+			overlap: &v1.Overlap{Policy: v1.OverlapPolicy_OVERLAP_INNERMOST},
+			expect:  map[string]time.Duration{"work": time.Hour, "meeting": time.Hour},
+			parts:   3,
+		},
+		{
+			name:    "innermost with partial overlaps",
+			events:  overlapping,
+			overlap: &v1.Overlap{Policy: v1.OverlapPolicy_OVERLAP_INNERMOST},
+			expect:  map[string]time.Duration{"work": time.Hour, "meeting": 30 * time.Minute, "sport": 150 * time.Minute},
+			parts:   3,
+		},
+		{
+			name:    "shortest",
+			events:  overlapping,
+			overlap: &v1.Overlap{Policy: v1.OverlapPolicy_OVERLAP_SHORTEST},
+			expect:  map[string]time.Duration{"work": time.Hour, "meeting": time.Hour, "sport": 2 * time.Hour},
+			parts:   3,
+		},
+		{
+			name:    "tag priority",
+			events:  overlapping,
+			overlap: &v1.Overlap{Policy: v1.OverlapPolicy_OVERLAP_TAG_PRIORITY, TagPriority: []string{"work"}},
+			expect:  map[string]time.Duration{"work": 3 * time.Hour, "meeting": 0, "sport": time.Hour},
+			parts:   2,
+		},
+		{
+			name:    "unlisted tags rank below listed ones",
+			events:  overlapping,
+			overlap: &v1.Overlap{Policy: v1.OverlapPolicy_OVERLAP_TAG_PRIORITY, TagPriority: []string{"sport", "meeting"}},
+			expect:  map[string]time.Duration{"work": time.Hour, "meeting": 30 * time.Minute, "sport": 150 * time.Minute},
+			parts:   3,
+		},
+		{
+			name:    "split",
+			events:  overlapping,
+			overlap: &v1.Overlap{Policy: v1.OverlapPolicy_OVERLAP_SPLIT},
+			expect:  map[string]time.Duration{"work": 115 * time.Minute, "meeting": 25 * time.Minute, "sport": 100 * time.Minute},
+			parts:   3,
+		},
+	}
+	for _, test := range table {
+		parts := resolveOverlaps(test.events, test.overlap)
+		got := map[string]time.Duration{}
+		for _, p := range parts {
+			got[p.Tags[0]] += p.duration
+			if p.Start.Before(test.events[p.origin].Start) || p.End.After(test.events[p.origin].End) {
+				t.Errorf("%s: part %s exceeds its event", test.name, p.Name)
+			}
+		}
+		for tag, expect := range test.expect {
+			if diff := got[tag] - expect; diff < -time.Second || diff > time.Second {
+				t.Errorf("%s: expected %s to count %v, got %v", test.name, tag, expect, got[tag])
+			}
+		}
+		if len(parts) != test.parts {
+			t.Errorf("%s: expected %d parts, got %d", test.name, test.parts, len(parts))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"calstats/internal/tel"
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is how long the config must stay unchanged before it is
+// reloaded, editors often write a file in several steps.
+const reloadDelay = 200 * time.Millisecond
+
+// watchConfig calls apply with the parsed config whenever the file at path
+// changes until ctx is done. Configs that can't be parsed or that apply
+// rejects are logged and otherwise ignored, so the previous config stays in
+// use.
+func watchConfig(ctx context.Context, path string, apply func(Config) error) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolve config path: %w", err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	// watch the directory instead of the file, editors replace the file by
+	// renaming a new one over it which would end a watch on the file itself
+	err = watcher.Add(filepath.Dir(path))
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch %s: %w", filepath.Dir(path), err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(reloadDelay)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				timer.Reset(reloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				tel.Log.Warn("config", "watch error", "err", err)
+			case <-timer.C:
+				cfg, err := parseConfig(path)
+				if err == nil {
+					err = apply(cfg)
+				}
+				if err != nil {
+					tel.Log.Warn("config", "rejected config, keeping the previous one", "path", path, "err", err)
+					continue
+				}
+				tel.Log.Info("config", "reloaded config", "path", path)
+			}
+		}
+	}()
+	return nil
+}
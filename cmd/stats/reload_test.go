@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json5")
+	write := func(contents string) {
+		t.Helper()
+		err := os.WriteFile(path, []byte(contents), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{port: 1}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	applied := make(chan Config, 4)
+	err := watchConfig(ctx, path, func(cfg Config) error {
+		applied <- cfg
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(port int) {
+		t.Helper()
+		select {
+		case cfg := <-applied:
+			if cfg.Port != port {
+				t.Errorf("expected port %d, got %d", port, cfg.Port)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected port %d to be applied", port)
+		}
+	}
+	expectNothing := func() {
+		t.Helper()
+		select {
+		case cfg := <-applied:
+			t.Errorf("expected nothing to be applied, got %+v", cfg)
+		case <-time.After(3 * reloadDelay):
+		}
+	}
+
+	write(`{port: 2}`)
+	expect(2)
+
+	// configs that can't be parsed are never applied
+	write(`{port: `)
+	expectNothing()
+
+	// replacing the file is noticed as well
+	next := filepath.Join(filepath.Dir(path), "next.json5")
+	err = os.WriteFile(next, []byte(`{port: 3}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(next, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(3)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/report"
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// runReport prints the time spent per category in the interval, counted like
+// the Stats RPC.
+func runReport(cfg Config, opts reportOptions) (err error) {
+	tz := time.Local
+	start, end, err := resolveInterval(opts.Interval, opts.Start, opts.End, tz)
+	if err != nil {
+		return
+	}
+	overlap, attribution, err := opts.counting()
+	if err != nil {
+		return
+	}
+	format, err := report.ParseFormat(opts.Format)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	state, err := newServiceState(cfg)
+	if err != nil {
+		return
+	}
+	interval := &v1.Interval{
+		Start: timestamppb.New(start),
+		End:   timestamppb.New(end),
+	}
+	events, err := state.loadEvents(ctx, interval, tz.String(), nil, nil)
+	if err != nil {
+		return
+	}
+	stats := categoryStats(resolveOverlaps(events, overlap), start, end, nil, attribution, state.separator)
+	return report.Render(os.Stdout, stats, format)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"calstats/internal/rules"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func runRules(cfg Config, opts rulesOptions) (err error) {
+	tz := time.Local
+	intvStart, intvEnd, err := parseDates(opts.Start, opts.End, tz)
+	if err != nil {
+		return
+	}
+
+	ruleset, err := rules.Compile(cfg.Rules)
+	if err != nil {
+		return fmt.Errorf("compile rules: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	sources, err := createSources(cfg)
+	if err != nil {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "START\tCALENDAR\tEVENT\tCATEGORIES\tRULE\tTAGS")
+	for _, source := range sources {
+		cals, err := source.selectedCalendars(ctx)
+		if err != nil {
+			return err
+		}
+		for _, cal := range cals {
+			events, err := source.Events(ctx, cal, intvStart, intvEnd, tz)
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				rule, ok := ruleset.Match(ruleSubject(source, cal, e))
+				if ok && opts.Unmatched {
+					continue
+				}
+				ruleName := "-"
+				tags := e.Tags
+				if ok {
+					ruleName = rule.Name
+					tags = rule.Tags
+				}
+				if len(tags) == 0 {
+					tags = []string{unknownCategory}
+				}
+				fmt.Fprintf(
+					w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					e.Start.In(tz).Format("2006-01-02 15:04"),
+					cal.Name,
+					e.Name,
+					strings.Join(e.Tags, ", "),
+					ruleName,
+					strings.Join(tags, ", "),
+				)
+			}
+		}
+	}
+	return w.Flush()
+}
@@ -4,11 +4,17 @@ import (
 	v1 "calstats/api/v1"
 	"calstats/internal/calendar"
 	"calstats/internal/config"
+	"calstats/internal/freebusy"
+	"calstats/internal/rules"
+	"container/heap"
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"connectrpc.com/connect"
@@ -17,14 +23,40 @@ import (
 )
 
 type CalendarService struct {
-	mutex       sync.Mutex
-	eventLookup []eventRef
-	sources     []sourceConfig
+	// each call works with the state it loaded when it started, so calls in
+	// flight finish with the old sources when the config is reloaded
+	state atomic.Pointer[serviceState]
+
+	// eventIds gives every event listed by Events an id that stays the same
+	// across calls and clients, so dashboards can update events whatever was
+	// listed since. The lookup only grows by events that were never listed
+	// before.
+	lookupMutex sync.Mutex
+	eventIds    map[eventKey]uint32
+	eventLookup map[uint32]eventRef
+}
+
+// eventKey identifies an event instance across calls.
+type eventKey struct {
+	source   string
+	calendar string
+	instance uint64
+}
+
+// serviceState is what the service derives from the config.
+type serviceState struct {
+	sources []sourceConfig
+	rules   rules.Rules
+	// separates the levels of hierarchical categories
+	separator string
+	// decides which events make the user busy
+	freeBusy freebusy.Classifier
 }
 
 type eventRef struct {
-	cal *calendar.Calendar
-	uid uint64
+	source calendar.Source
+	cal    *calendar.Calendar
+	uid    uint64
 }
 
 type sourceConfig struct {
@@ -32,49 +64,94 @@ type sourceConfig struct {
 	cfg config.Source
 }
 
-func NewCalendarService(sources []sourceConfig) *CalendarService {
-	return &CalendarService{
-		sources: sources,
+// selectedCalendars returns the calendars of the source that are included
+// by the config.
+func (s sourceConfig) selectedCalendars(ctx context.Context) ([]calendar.Calendar, error) {
+	cals, err := s.Calendars(ctx)
+	if err != nil {
+		return nil, err
 	}
+	var filtered []calendar.Calendar
+	for _, c := range cals {
+		if slices.Contains(s.cfg.Calendars, c.Name) {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("find calendar: not found '%s'", s.cfg.Calendars)
+	}
+	return filtered, nil
 }
 
-func (s *CalendarService) Events(ctx context.Context, req *connect.Request[v1.EventsRequest]) (*connect.Response[v1.EventsResponse], error) {
-	defer s.mutex.Unlock()
-	s.mutex.Lock()
+func NewCalendarService(state *serviceState) *CalendarService {
+	s := &CalendarService{
+		eventIds:    map[eventKey]uint32{},
+		eventLookup: map[uint32]eventRef{},
+	}
+	s.state.Store(state)
+	return s
+}
 
-	tagIdxTable := map[string]uint32{}
-	nameIdxTable := map[string]uint32{}
-	curTagIdx := uint32(0)
-	curNameIdx := uint32(0)
+// Reload replaces the sources and rules for the calls started afterwards.
+func (s *CalendarService) Reload(state *serviceState) {
+	s.state.Store(state)
+}
 
-	var pbEvents []*v1.Event
+// ruleSubject returns what the categorisation rules match the event against.
+func ruleSubject(source sourceConfig, cal calendar.Calendar, event calendar.Event) rules.Subject {
+	return rules.Subject{
+		Name:        event.Name,
+		Location:    event.Location,
+		Description: event.Description,
+		Calendar:    cal.Name,
+		Source:      source.cfg.Origin(),
+		Tagged:      len(event.Tags) > 0,
+	}
+}
 
-	for _, source := range s.sources {
-		cals, err := source.Calendars(ctx)
-		if err != nil {
-			return nil, err
-		}
-		var filtered []calendar.Calendar
-		for _, c := range cals {
-			if slices.Contains(source.cfg.Calendars, c.Name) {
-				filtered = append(filtered, c)
-			}
-		}
-		if len(filtered) == 0 {
-			return nil, fmt.Errorf("find calendar: not found '%s'", source.cfg.Calendars)
-		}
+// countedEvent is an event together with the time it counts towards the
+// statistics.
+type countedEvent struct {
+	calendar.Event
+	// the index of the event as loaded, it is shared by the parts of an
+	// event split by resolveOverlaps
+	origin     int
+	source     sourceConfig
+	cal        calendar.Calendar
+	duration   time.Duration
+	background bool
+}
+
+// loadEvents loads the events in the interval from every source, applying the
+// text filters, the categorisation rules and the all-day policy of each
+// source.
+func (st *serviceState) loadEvents(ctx context.Context, interval *v1.Interval, tzId string, location, description *v1.TextFilter) ([]countedEvent, error) {
+	matchLocation, err := compileTextFilter(location)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("location filter: %w", err))
+	}
+	matchDescription, err := compileTextFilter(description)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("description filter: %w", err))
+	}
 
-		tzId := req.Msg.Timezone
-		tz, err := time.LoadLocation(tzId)
+	tz, err := time.LoadLocation(tzId)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone: %w", err)
+	}
+
+	var out []countedEvent
+	for _, source := range st.sources {
+		filtered, err := source.selectedCalendars(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("load timezone: %w", err)
+			return nil, err
 		}
 
 		for _, cal := range filtered {
 			eventList, err := source.Events(
 				ctx, cal,
-				req.Msg.Interval.Start.AsTime(),
-				req.Msg.Interval.End.AsTime(),
+				interval.Start.AsTime(),
+				interval.End.AsTime(),
 				tz,
 			)
 			if err != nil {
@@ -82,59 +159,133 @@ func (s *CalendarService) Events(ctx context.Context, req *connect.Request[v1.Ev
 			}
 
 			for _, event := range eventList {
-				var tags []uint32
-				if len(event.Tags) > 0 {
-					tags = make([]uint32, len(event.Tags))
-					for i, tagName := range event.Tags {
-						tagIdx, ok := tagIdxTable[tagName]
-						if !ok {
-							tagIdxTable[tagName] = curTagIdx
-							tagIdx = curTagIdx
-							curTagIdx++
-						}
-						tags[i] = tagIdx
-					}
+				if !matchLocation(event.Location) || !matchDescription(event.Description) {
+					continue
+				}
+				if rule, ok := st.rules.Match(ruleSubject(source, cal, event)); ok {
+					event.Tags = rule.Tags
+				}
+				duration, background, ok := countedDuration(event, source.cfg.AllDay)
+				if !ok {
+					continue
 				}
+				out = append(out, countedEvent{
+					Event:      event,
+					origin:     len(out),
+					source:     source,
+					cal:        cal,
+					duration:   duration,
+					background: background,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *CalendarService) Events(ctx context.Context, req *connect.Request[v1.EventsRequest]) (*connect.Response[v1.EventsResponse], error) {
+	err := checkInterval(req.Msg.Interval)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.state.Load().loadEvents(ctx, req.Msg.Interval, req.Msg.Timezone, req.Msg.Location, req.Msg.Description)
+	if err != nil {
+		return nil, err
+	}
+	events = resolveOverlaps(events, req.Msg.Overlap)
 
-				id := len(s.eventLookup)
-				s.eventLookup = append(s.eventLookup, eventRef{
-					cal: &cal,
-					uid: event.Id,
+	s.lookupMutex.Lock()
+	defer s.lookupMutex.Unlock()
+
+	tagIdxTable := map[string]uint32{}
+	nameIdxTable := map[string]uint32{}
+	curTagIdx := uint32(0)
+	curNameIdx := uint32(0)
+
+	var pbEvents []*v1.Event
+	// parts of the same event share its id
+	originIds := map[int]uint32{}
+
+	indexTag := func(tag string) uint32 {
+		tagIdx, ok := tagIdxTable[tag]
+		if !ok {
+			tagIdxTable[tag] = curTagIdx
+			tagIdx = curTagIdx
+			curTagIdx++
+		}
+		return tagIdx
+	}
+
+	for _, event := range events {
+		var tags []uint32
+		var attribution []*v1.Attribution
+		if len(event.Tags) > 0 {
+			tags = make([]uint32, len(event.Tags))
+			for i, tag := range event.Tags {
+				tags[i] = indexTag(tag)
+			}
+			for _, share := range attribute(event, req.Msg.Attribution) {
+				attribution = append(attribution, &v1.Attribution{
+					Tag:      indexTag(share.tag),
+					Duration: durationpb.New(share.duration),
 				})
+			}
+		}
 
-				nameIdx, ok := nameIdxTable[event.Name]
-				if !ok {
-					nameIdxTable[event.Name] = curNameIdx
-					nameIdx = curNameIdx
-					curNameIdx++
-				}
+		id, ok := originIds[event.origin]
+		if !ok {
+			key := eventKey{
+				source:   event.source.cfg.Origin(),
+				calendar: event.cal.Id,
+				instance: event.Id,
+			}
+			id, ok = s.eventIds[key]
+			if !ok {
+				id = uint32(len(s.eventIds))
+				s.eventIds[key] = id
+			}
+			originIds[event.origin] = id
+			// the source is replaced by the one of the current config
+			s.eventLookup[id] = eventRef{
+				source: event.source.Source,
+				cal:    &event.cal,
+				uid:    event.Id,
+			}
+		}
 
-				eventOutput := &v1.Event{
-					Id:          uint32(id),
-					Name:        nameIdx,
-					Location:    event.Location,
-					Description: event.Description,
-					Tags:        tags,
-					Interval: &v1.Interval{
-						Start: timestamppb.New(event.Start),
-						End:   timestamppb.New(event.End),
-					},
-					Duration: durationpb.New(event.Duration()),
-				}
-				if event.Trigger.Absolute != (time.Time{}) {
-					eventOutput.Trigger = &v1.Event_Absolute{
-						Absolute: timestamppb.New(event.Trigger.Absolute),
-					}
-				} else if event.Trigger.NotNone {
-					eventOutput.Trigger = &v1.Event_Relative{
-						Relative: durationpb.New(event.Trigger.Relative),
-					}
-				}
+		nameIdx, ok := nameIdxTable[event.Name]
+		if !ok {
+			nameIdxTable[event.Name] = curNameIdx
+			nameIdx = curNameIdx
+			curNameIdx++
+		}
 
-				pbEvents = append(pbEvents, eventOutput)
+		eventOutput := &v1.Event{
+			Id:          id,
+			Name:        nameIdx,
+			Location:    event.Location,
+			Description: event.Description,
+			Tags:        tags,
+			Interval: &v1.Interval{
+				Start: timestamppb.New(event.Start),
+				End:   timestamppb.New(event.End),
+			},
+			Duration:    durationpb.New(event.duration),
+			AllDay:      event.AllDay,
+			Background:  event.background,
+			Attribution: attribution,
+		}
+		if event.Trigger.Absolute != (time.Time{}) {
+			eventOutput.Trigger = &v1.Event_Absolute{
+				Absolute: timestamppb.New(event.Trigger.Absolute),
+			}
+		} else if event.Trigger.NotNone {
+			eventOutput.Trigger = &v1.Event_Relative{
+				Relative: durationpb.New(event.Trigger.Relative),
 			}
 		}
 
+		pbEvents = append(pbEvents, eventOutput)
 	}
 
 	slices.SortFunc(pbEvents, func(a, b *v1.Event) int {
@@ -162,11 +313,72 @@ func (s *CalendarService) Events(ctx context.Context, req *connect.Request[v1.Ev
 	}), nil
 }
 
+// compileTextFilter returns a function that reports whether a value matches
+// the filter, every value matches an unset filter.
+func compileTextFilter(filter *v1.TextFilter) (func(string) bool, error) {
+	if filter == nil || filter.Pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+	if filter.Regex {
+		re, err := regexp.Compile(filter.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	pattern := strings.ToLower(filter.Pattern)
+	return func(value string) bool {
+		return strings.Contains(strings.ToLower(value), pattern)
+	}, nil
+}
+
+// countedDuration returns the time an event counts towards the statistics,
+// which depends on the all-day policy for all-day events. It returns false if
+// the event should be left out.
+func countedDuration(event calendar.Event, policy config.AllDay) (duration time.Duration, background bool, ok bool) {
+	if !event.AllDay {
+		return event.Duration(), false, true
+	}
+	switch policy.Policy {
+	case config.AllDayExclude:
+		return 0, false, false
+	case config.AllDayBackground:
+		return 0, true, true
+	case config.AllDayHours:
+		return time.Duration(dayShare(event.Start, event.End) * policy.Hours * float64(time.Hour)), false, true
+	default:
+		return event.Duration(), false, true
+	}
+}
+
+// dayShare returns how many days the range covers, in the location of start.
+// Every day counts by the share of it that is covered, so days shortened by
+// DST count as a whole day and days cut by the interval bounds only partly.
+func dayShare(start, end time.Time) float64 {
+	var days float64
+	year, month, day := start.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, start.Location())
+	for dayStart.Before(end) {
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		from, to := dayStart, dayEnd
+		if start.After(from) {
+			from = start
+		}
+		if end.Before(to) {
+			to = end
+		}
+		days += float64(to.Sub(from)) / float64(dayEnd.Sub(dayStart))
+		dayStart = dayEnd
+	}
+	return days
+}
+
 func (s *CalendarService) Calendar(ctx context.Context, req *connect.Request[v1.CalendarRequest]) (*connect.Response[v1.CalendarResponse], error) {
-	sources := make([]*v1.CalendarResponse_Source, len(s.sources))
-	for i, s := range s.sources {
+	state := s.state.Load()
+	sources := make([]*v1.CalendarResponse_Source, len(state.sources))
+	for i, s := range state.sources {
 		sources[i] = &v1.CalendarResponse_Source{
-			CalendarServer: s.cfg.Server.Url,
+			CalendarServer: s.cfg.Origin(),
 			Names:          s.cfg.Calendars,
 		}
 	}
@@ -175,102 +387,160 @@ func (s *CalendarService) Calendar(ctx context.Context, req *connect.Request[v1.
 	}), nil
 }
 
-func DeoverlapEvents(eventList *[]calendar.Event) {
-	// tel.Log.Debug("deoverlap", "=======")
-
-	if len(*eventList) < 2 {
-		return
-	}
+func (s *CalendarService) UpdateEvents(ctx context.Context, req *connect.Request[v1.UpdateEventsRequest]) (*connect.Response[v1.UpdateEventsResponse], error) {
+	results := make([]*v1.UpdateEventsResponse_Result, len(req.Msg.Events))
+	for i, pbUpdate := range req.Msg.Events {
+		results[i] = &v1.UpdateEventsResponse_Result{Id: pbUpdate.Id}
+
+		// events keep referring to the source they were loaded from, even
+		// if the config was reloaded since
+		s.lookupMutex.Lock()
+		ref, known := s.eventLookup[pbUpdate.Id]
+		s.lookupMutex.Unlock()
+		if !known {
+			results[i].Error = fmt.Sprintf("unknown event id %d", pbUpdate.Id)
+			continue
+		}
 
-	type event struct {
-		id int
-		calendar.Event
-	}
+		update := calendar.UpdateEvent{
+			Id:          ref.uid,
+			Name:        pbUpdate.Name,
+			Location:    pbUpdate.Location,
+			Description: pbUpdate.Description,
+		}
+		if pbUpdate.Tags != nil {
+			tags := pbUpdate.Tags.Tags
+			update.Tags = &tags
+		}
+		if pbUpdate.Interval != nil {
+			if pbUpdate.Interval.Start != nil {
+				start := pbUpdate.Interval.Start.AsTime()
+				update.Start = &start
+			}
+			if pbUpdate.Interval.End != nil {
+				end := pbUpdate.Interval.End.AsTime()
+				update.End = &end
+			}
+		}
+		switch trigger := pbUpdate.Trigger.(type) {
+		case *v1.EventUpdate_Relative:
+			update.Trigger = &calendar.EventTrigger{
+				Relative: trigger.Relative.AsDuration(),
+				NotNone:  true,
+			}
+		case *v1.EventUpdate_Absolute:
+			update.Trigger = &calendar.EventTrigger{
+				Absolute: trigger.Absolute.AsTime(),
+				NotNone:  true,
+			}
+		case *v1.EventUpdate_None:
+			update.Trigger = &calendar.EventTrigger{}
+		}
 
-	events := make([]event, len(*eventList))
-	for i, e := range *eventList {
-		events[i] = event{
-			id:    i,
-			Event: e,
+		err := ref.source.Update(ctx, []calendar.UpdateEvent{update})
+		if err != nil {
+			results[i].Error = err.Error()
 		}
 	}
 
-	for i := 1; i < len(events); i++ {
-		a := events[i-1]
-		b := events[i]
-
-		// for _, e := range events {
-		// 	fmt.Println(e.Name, e.Start.Format(time.Kitchen), e.End.Format(time.Kitchen))
-		// }
-
-		// Case where B is inside A
-		if a.Start.Before(b.Start) && b.End.Before(a.End) {
-			// tel.Log.Debug("deoverlap", "case: B inside A")
-
-			events = slices.Insert(events, i+1, event{
-				id: i - 1,
-				Event: calendar.Event{
-					Id:    a.Id,
-					Name:  a.Name,
-					Start: b.End,
-					End:   a.End,
-					Tags:  a.Tags,
-				},
-			})
-			a.End = b.Start
-			events[i-1] = a
-			continue
-		}
+	return connect.NewResponse(&v1.UpdateEventsResponse{
+		Results: results,
+	}), nil
+}
 
-		// Case where B is at the start of A but less long than A
-		if a.Start.Equal(b.Start) && b.End.Before(a.End) {
-			// tel.Log.Debug("deoverlap", "case: B.start = A.start but B.end < A.end")
+// innermostHeap orders events so that the innermost one is at the top: the
+// event that started last, on equal starts the one that ends first.
+type innermostHeap struct {
+	events []calendar.Event
+	idx    []int
+}
 
-			a.Start = b.End
-			// swap positions since A starts later than B now
-			events[i-1] = b
-			events[i] = a
+func (h innermostHeap) Len() int { return len(h.idx) }
 
-			continue
-		}
+func (h innermostHeap) Less(i, j int) bool {
+	a, b := h.events[h.idx[i]], h.events[h.idx[j]]
+	if !a.Start.Equal(b.Start) {
+		return a.Start.After(b.Start)
+	}
+	if !a.End.Equal(b.End) {
+		return a.End.Before(b.End)
+	}
+	// later events in the list win ties
+	return h.idx[i] > h.idx[j]
+}
 
-		// Cases where B's end is after or equal to A's end
-		if b.End.After(a.End) || b.End.Equal(a.End) {
-			// Case where B starts in A but does not end in A
-			if b.Start.After(a.Start) && b.Start.Before(a.End) {
-				// tel.Log.Debug("deoverlap", "case: B starts in A but does not end in A")
+func (h innermostHeap) Swap(i, j int) { h.idx[i], h.idx[j] = h.idx[j], h.idx[i] }
 
-				a.End = b.Start
-				events[i-1] = a
-				continue
-			}
+func (h *innermostHeap) Push(x any) { h.idx = append(h.idx, x.(int)) }
 
-			// Case where B ends in A but does not start in A
-			if a.Start.After(b.Start) && a.Start.Before(b.End) {
-				// tel.Log.Debug("deoverlap", "case: B ends in A but does not start in A")
+func (h *innermostHeap) Pop() any {
+	x := h.idx[len(h.idx)-1]
+	h.idx = h.idx[:len(h.idx)-1]
+	return x
+}
 
-				b.End = a.Start
-				events[i] = b
-				continue
-			}
-		}
+// DeoverlapEvents replaces the events with non-overlapping parts of them,
+// ordered by start. Wherever events overlap the innermost event takes the
+// time, that is the one that started last or, on equal starts, the one that
+// ends first. The parts keep every field of their event besides the interval,
+// events without length are dropped.
+//
+// It sweeps over the starts and ends of the events with a heap of the events
+// in progress, so it runs in O(n log n).
+func DeoverlapEvents(eventList *[]calendar.Event) {
+	events := *eventList
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return events[a].Start.Compare(events[b].Start)
+	})
+
+	var out []calendar.Event
+	// the event of the last part in out
+	last := -1
+	active := &innermostHeap{events: events}
 
-		// Case where A and B are touching each other and are the same event
-		if a.id == b.id && a.End.Equal(b.Start) {
-			a.End = b.End
-			events[i-1] = a
-			events = slices.Delete(events, i, i+1)
+	next := 0
+	var cur time.Time
+	for next < len(order) || active.Len() > 0 {
+		if active.Len() == 0 {
+			cur = events[order[next]].Start
+		}
+		for next < len(order) && !events[order[next]].Start.After(cur) {
+			heap.Push(active, order[next])
+			next++
+		}
+		// events that ended are only removed once they reach the top, as
+		// they can't take any time after their end
+		for active.Len() > 0 && !events[active.idx[0]].End.After(cur) {
+			heap.Pop(active)
+		}
+		if active.Len() == 0 {
+			continue
 		}
-	}
 
-	// for _, e := range events {
-	// 	fmt.Println(e.Name, e.Start.Format(time.Kitchen), e.End.Format(time.Kitchen))
-	// }
+		// the innermost event keeps the time until it ends or another
+		// event starts
+		top := active.idx[0]
+		end := events[top].End
+		if next < len(order) && events[order[next]].Start.Before(end) {
+			end = events[order[next]].Start
+		}
 
-	out := make([]calendar.Event, len(events))
-	for i, e := range events {
-		out[i] = e.Event
+		if last == top && out[len(out)-1].End.Equal(cur) {
+			out[len(out)-1].End = end
+		} else {
+			part := events[top]
+			part.Start = cur
+			part.End = end
+			out = append(out, part)
+			last = top
+		}
+		cur = end
 	}
+
 	*eventList = out
 }
 
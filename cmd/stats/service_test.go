@@ -1,9 +1,19 @@
 package main
 
 import (
+	v1 "calstats/api/v1"
 	"calstats/internal/calendar"
+	"calstats/internal/config"
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"testing"
 	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestLayerEvents(t *testing.T) {
@@ -54,6 +64,23 @@ func TestLayerEvents(t *testing.T) {
 				{Id: 2, Name: "B", Tags: nil, Start: datetime(9, 40), End: datetime(9, 45)},
 			},
 		},
+		{
+			// three levels of nesting, the innermost events start together
+			input: []calendar.Event{
+				{Id: 1, Name: "A", Tags: nil, Start: datetime(9, 0), End: datetime(13, 0)},
+				{Id: 2, Name: "B", Tags: nil, Start: datetime(10, 0), End: datetime(12, 0)},
+				{Id: 3, Name: "C", Tags: nil, Start: datetime(10, 30), End: datetime(11, 30)},
+				{Id: 4, Name: "D", Tags: nil, Start: datetime(10, 30), End: datetime(11, 0)},
+			},
+			expect: []calendar.Event{
+				{Id: 1, Name: "A", Tags: nil, Start: datetime(9, 0), End: datetime(10, 0)},
+				{Id: 2, Name: "B", Tags: nil, Start: datetime(10, 0), End: datetime(10, 30)},
+				{Id: 4, Name: "D", Tags: nil, Start: datetime(10, 30), End: datetime(11, 0)},
+				{Id: 3, Name: "C", Tags: nil, Start: datetime(11, 0), End: datetime(11, 30)},
+				{Id: 2, Name: "B", Tags: nil, Start: datetime(11, 30), End: datetime(12, 0)},
+				{Id: 1, Name: "A", Tags: nil, Start: datetime(12, 0), End: datetime(13, 0)},
+			},
+		},
 	}
 
 	for _, test := range table {
@@ -82,8 +109,253 @@ func TestLayerEvents(t *testing.T) {
 	}
 }
 
+func TestDeoverlapEventsProperties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	base := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	minute := func(m int) time.Time {
+		return base.Add(time.Duration(m) * time.Minute)
+	}
+
+	for round := 0; round < 500; round++ {
+		// a small time range forces deep nesting and equal bounds
+		input := make([]calendar.Event, 1+rng.IntN(30))
+		for i := range input {
+			start := rng.IntN(120)
+			input[i] = calendar.Event{
+				Id:    uint64(i),
+				Name:  fmt.Sprint(i),
+				Start: minute(start),
+				End:   minute(start + rng.IntN(60)),
+			}
+		}
+		result := slices.Clone(input)
+		DeoverlapEvents(&result)
+
+		// no parts overlap and every part lies within its event
+		for i, part := range result {
+			if !part.Start.Before(part.End) {
+				t.Fatalf("round %d: empty part %s", round, prettyPrint(part))
+			}
+			if i > 0 && part.Start.Before(result[i-1].End) {
+				t.Fatalf("round %d: parts overlap\n\nInput: %s\n\nResult: %s", round, prettyPrint(input), prettyPrint(result))
+			}
+			event := input[part.Id]
+			if part.Start.Before(event.Start) || part.End.After(event.End) {
+				t.Fatalf("round %d: part %s exceeds its event", round, prettyPrint(part))
+			}
+		}
+
+		// every covered minute is still covered, by the innermost event
+		for m := 0; m < 180; m++ {
+			at := minute(m)
+			innermost := -1
+			for i, e := range input {
+				if e.Start.After(at) || !e.End.After(at) {
+					continue
+				}
+				if innermost < 0 ||
+					e.Start.After(input[innermost].Start) ||
+					e.Start.Equal(input[innermost].Start) && !e.End.After(input[innermost].End) {
+					innermost = i
+				}
+			}
+			covering := -1
+			for _, part := range result {
+				if !part.Start.After(at) && part.End.After(at) {
+					covering = int(part.Id)
+				}
+			}
+			if covering != innermost {
+				t.Fatalf(
+					"round %d: minute %d is covered by %d instead of %d\n\nInput: %s\n\nResult: %s",
+					round, m, covering, innermost, prettyPrint(input), prettyPrint(result),
+				)
+			}
+		}
+	}
+}
+
 func equalEvents(a, b calendar.Event) bool {
 	return a.Name == b.Name &&
 		a.Start.Equal(b.Start) &&
 		a.End.Equal(b.End)
-}
\ No newline at end of file
+}
+
+func TestCountedDuration(t *testing.T) {
+	vacation := calendar.Event{
+		Name:   "Vacation",
+		Start:  time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC),
+		AllDay: true,
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the day DST starts only has 23 hours
+	dst := calendar.Event{
+		Name:   "DST",
+		Start:  time.Date(2024, time.March, 31, 0, 0, 0, 0, berlin),
+		End:    time.Date(2024, time.April, 1, 0, 0, 0, 0, berlin),
+		AllDay: true,
+	}
+	// cut by the interval at noon
+	cropped := calendar.Event{
+		Name:   "Cropped",
+		Start:  time.Date(2024, time.January, 8, 12, 0, 0, 0, time.UTC),
+		End:    time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC),
+		AllDay: true,
+	}
+	meeting := calendar.Event{
+		Name:  "Meeting",
+		Start: time.Date(2024, time.January, 8, 9, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, time.January, 8, 10, 0, 0, 0, time.UTC),
+	}
+
+	table := []struct {
+		event      calendar.Event
+		policy     config.AllDay
+		duration   time.Duration
+		background bool
+		ok         bool
+	}{
+		{vacation, config.AllDay{}, 48 * time.Hour, false, true},
+		{vacation, config.AllDay{Policy: config.AllDayExclude}, 0, false, false},
+		{vacation, config.AllDay{Policy: config.AllDayHours, Hours: 8}, 16 * time.Hour, false, true},
+		{dst, config.AllDay{Policy: config.AllDayHours, Hours: 8}, 8 * time.Hour, false, true},
+		{cropped, config.AllDay{Policy: config.AllDayHours, Hours: 8}, 12 * time.Hour, false, true},
+		{vacation, config.AllDay{Policy: config.AllDayBackground}, 0, true, true},
+		{meeting, config.AllDay{Policy: config.AllDayExclude}, time.Hour, false, true},
+	}
+	for _, test := range table {
+		duration, background, ok := countedDuration(test.event, test.policy)
+		if duration != test.duration || background != test.background || ok != test.ok {
+			t.Errorf(
+				"%s with policy %+v: expected (%v, %v, %v), got (%v, %v, %v)",
+				test.event.Name, test.policy,
+				test.duration, test.background, test.ok,
+				duration, background, ok,
+			)
+		}
+	}
+}
+
+func TestCompileTextFilter(t *testing.T) {
+	table := []struct {
+		filter *v1.TextFilter
+		value  string
+		match  bool
+	}{
+		{nil, "Room 4.12", true},
+		{&v1.TextFilter{Pattern: "room 4"}, "Room 4.12", true},
+		{&v1.TextFilter{Pattern: "room 5"}, "Room 4.12", false},
+		{&v1.TextFilter{Pattern: `PRJ-\d+`, Regex: true}, "project PRJ-42", true},
+		{&v1.TextFilter{Pattern: `^PRJ-\d+`, Regex: true}, "project PRJ-42", false},
+	}
+	for _, test := range table {
+		match, err := compileTextFilter(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match(test.value) != test.match {
+			t.Errorf("filter %v on %q: expected %v", test.filter, test.value, test.match)
+		}
+	}
+
+	_, err := compileTextFilter(&v1.TextFilter{Pattern: "(", Regex: true})
+	if err == nil {
+		t.Fatal("expected an invalid regex to be rejected")
+	}
+}
+
+// fakeSource serves fixed events and records the updates it is given.
+type fakeSource struct {
+	events  []calendar.Event
+	updates *[]calendar.UpdateEvent
+	err     error
+}
+
+func (f fakeSource) Calendars(ctx context.Context) ([]calendar.Calendar, error) {
+	return []calendar.Calendar{{Id: "work", Name: "work"}}, nil
+}
+
+func (f fakeSource) Events(ctx context.Context, cal calendar.Calendar, start, end time.Time, tz *time.Location) ([]calendar.Event, error) {
+	return f.events, nil
+}
+
+func (f fakeSource) Update(ctx context.Context, events []calendar.UpdateEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	*f.updates = append(*f.updates, events...)
+	return nil
+}
+
+func TestUpdateEvents(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, time.January, 1, hour, 0, 0, 0, time.UTC)
+	}
+	var updates []calendar.UpdateEvent
+	working := fakeSource{
+		events:  []calendar.Event{{Id: 10, Name: "Standup", Start: at(9), End: at(10)}},
+		updates: &updates,
+	}
+	failing := fakeSource{
+		events: []calendar.Event{{Id: 20, Name: "Review", Start: at(11), End: at(12)}},
+		err:    fmt.Errorf("precondition failed"),
+	}
+	service := NewCalendarService(&serviceState{
+		sources: []sourceConfig{
+			{Source: working, cfg: config.Source{Path: "working.ics", Calendars: []string{"work"}}},
+			{Source: failing, cfg: config.Source{Path: "failing.ics", Calendars: []string{"work"}}},
+		},
+	})
+
+	loadIds := func() map[string]uint32 {
+		res, err := service.Events(context.Background(), connect.NewRequest(&v1.EventsRequest{
+			Interval: &v1.Interval{Start: timestamppb.New(at(0)), End: timestamppb.New(at(24))},
+			Timezone: "UTC",
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := map[string]uint32{}
+		for _, e := range res.Msg.Events {
+			ids[res.Msg.EventNames[e.Name]] = e.Id
+		}
+		return ids
+	}
+	ids := loadIds()
+	if again := loadIds(); !maps.Equal(again, ids) {
+		t.Fatalf("expected the same ids for every Events call, got %v and %v", ids, again)
+	}
+
+	name := "Planning"
+	res, err := service.UpdateEvents(context.Background(), connect.NewRequest(&v1.UpdateEventsRequest{
+		Events: []*v1.EventUpdate{
+			{Id: ids["Standup"], Name: &name, Interval: &v1.Interval{End: timestamppb.New(at(11))}},
+			{Id: ids["Review"], Name: &name},
+			{Id: 1000, Name: &name},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := res.Msg.Results
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Error != "" {
+		t.Errorf("expected the update to succeed, got %s", results[0].Error)
+	}
+	if len(updates) != 1 || updates[0].Id != 10 || *updates[0].Name != name || !updates[0].End.Equal(at(11)) || updates[0].Start != nil {
+		t.Errorf("unexpected updates %+v", updates)
+	}
+	if results[1].Error != "precondition failed" {
+		t.Errorf("expected the error of the source, got %q", results[1].Error)
+	}
+	if results[2].Error != "unknown event id 1000" {
+		t.Errorf("expected unknown event id, got %q", results[2].Error)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// unknownCategory collects untagged events and the time not covered by any
+// event.
+const unknownCategory = "Unknown"
+
+type categoryTotal struct {
+	name     string
+	time     time.Duration
+	events   uint32
+	disabled bool
+}
+
+// categoryStats computes the time spent in each category in the interval,
+// matching the statistics shown on the dashboard. The time of an event is
+// attributed to its tags by the attribution mode, background events don't
+// consume time. The categories are also returned as a tree, split by the
+// separator.
+func categoryStats(events []countedEvent, start, end time.Time, disabled []string, attribution v1.TagAttribution, separator string) *v1.StatsResponse {
+	categories := map[string]*categoryTotal{}
+	category := func(name string) *categoryTotal {
+		cat, ok := categories[name]
+		if !ok {
+			cat = &categoryTotal{
+				name:     name,
+				disabled: slices.Contains(disabled, name),
+			}
+			categories[name] = cat
+		}
+		return cat
+	}
+	unknown := category(unknownCategory)
+
+	var tracked, disabledTime time.Duration
+	type eventInCategory struct {
+		category string
+		origin   int
+	}
+	counted := map[eventInCategory]bool{}
+	for _, e := range events {
+		if e.background {
+			continue
+		}
+		for _, share := range attribute(e, attribution) {
+			cat := category(share.tag)
+			cat.time += share.duration
+			key := eventInCategory{share.tag, e.origin}
+			if !counted[key] {
+				counted[key] = true
+				cat.events++
+			}
+			if cat.disabled {
+				disabledTime += share.duration
+			}
+		}
+		// tracked time is counted regardless of disabled categories
+		tracked += e.duration
+	}
+
+	interval := end.Sub(start)
+	untracked := interval - tracked
+	unknown.time += untracked
+	if unknown.disabled {
+		disabledTime += untracked
+	}
+	total := interval - disabledTime
+
+	out := make([]*v1.CategoryStat, 0, len(categories))
+	for _, cat := range categories {
+		stat := &v1.CategoryStat{
+			Name:     cat.name,
+			Time:     durationpb.New(cat.time),
+			Events:   cat.events,
+			Disabled: cat.disabled,
+		}
+		if !cat.disabled && total > 0 {
+			stat.Proportion = float64(cat.time) / float64(total)
+		}
+		out = append(out, stat)
+	}
+	slices.SortFunc(out, func(a, b *v1.CategoryStat) int {
+		diff := cmp.Compare(b.Time.AsDuration(), a.Time.AsDuration())
+		if diff != 0 {
+			return diff
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return &v1.StatsResponse{
+		Categories: out,
+		Total:      durationpb.New(total),
+		Tracked:    durationpb.New(tracked),
+		Untracked:  durationpb.New(untracked),
+		Tree:       categoryTree(out, total, separator),
+	}
+}
+
+// categoryTree splits the paths of the enabled categories by the separator
+// and sums up the time of each subtree.
+func categoryTree(categories []*v1.CategoryStat, total time.Duration, separator string) *v1.CategoryNode {
+	type node struct {
+		*v1.CategoryNode
+		self, subtree time.Duration
+		children      map[string]*node
+	}
+	root := &node{CategoryNode: &v1.CategoryNode{}, children: map[string]*node{}}
+	for _, cat := range categories {
+		if cat.Disabled {
+			continue
+		}
+		segments := []string{cat.Name}
+		if separator != "" {
+			segments = strings.Split(cat.Name, separator)
+		}
+		cur := root
+		cur.subtree += cat.Time.AsDuration()
+		for i, segment := range segments {
+			child, ok := cur.children[segment]
+			if !ok {
+				child = &node{
+					CategoryNode: &v1.CategoryNode{
+						Name: segment,
+						Path: strings.Join(segments[:i+1], separator),
+					},
+					children: map[string]*node{},
+				}
+				cur.children[segment] = child
+			}
+			cur = child
+			cur.subtree += cat.Time.AsDuration()
+		}
+		cur.self += cat.Time.AsDuration()
+	}
+
+	var build func(n *node) *v1.CategoryNode
+	build = func(n *node) *v1.CategoryNode {
+		n.Self = durationpb.New(n.self)
+		n.Subtree = durationpb.New(n.subtree)
+		if total > 0 {
+			n.Proportion = float64(n.subtree) / float64(total)
+		}
+		for _, child := range n.children {
+			n.Children = append(n.Children, build(child))
+		}
+		slices.SortFunc(n.Children, func(a, b *v1.CategoryNode) int {
+			diff := cmp.Compare(b.Subtree.AsDuration(), a.Subtree.AsDuration())
+			if diff != 0 {
+				return diff
+			}
+			return cmp.Compare(a.Name, b.Name)
+		})
+		return n.CategoryNode
+	}
+	return build(root)
+}
+
+// checkInterval rejects requests without a complete interval.
+func checkInterval(interval *v1.Interval) error {
+	if interval == nil || interval.Start == nil || interval.End == nil {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("interval needs a start and an end"))
+	}
+	return nil
+}
+
+func (s *CalendarService) Stats(ctx context.Context, req *connect.Request[v1.StatsRequest]) (*connect.Response[v1.StatsResponse], error) {
+	err := checkInterval(req.Msg.Interval)
+	if err != nil {
+		return nil, err
+	}
+	state := s.state.Load()
+	events, err := state.loadEvents(ctx, req.Msg.Interval, req.Msg.Timezone, req.Msg.Location, req.Msg.Description)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(categoryStats(
+		resolveOverlaps(events, req.Msg.Overlap),
+		req.Msg.Interval.Start.AsTime(),
+		req.Msg.Interval.End.AsTime(),
+		req.Msg.Disabled,
+		req.Msg.Attribution,
+		state.separator,
+	)), nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	v1 "calstats/api/v1"
+	"calstats/internal/calendar"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCategoryStats(t *testing.T) {
+	start := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Hour)
+	origin := 0
+	event := func(duration time.Duration, background bool, tags ...string) countedEvent {
+		origin++
+		return countedEvent{
+			Event:      calendar.Event{Tags: tags},
+			origin:     origin,
+			duration:   duration,
+			background: background,
+		}
+	}
+	events := []countedEvent{
+		event(2*time.Hour, false, "work", "meeting"),
+		event(time.Hour, false, "work"),
+		event(2*time.Hour, false, "sport"),
+		event(time.Hour, false),
+		event(24*time.Hour, true, "vacation"),
+	}
+
+	type expectStat struct {
+		time       time.Duration
+		proportion float64
+		events     uint32
+	}
+	table := []struct {
+		disabled []string
+		total    time.Duration
+		expect   map[string]expectStat
+	}{
+		{
+			total: 10 * time.Hour,
+			expect: map[string]expectStat{
+				"work":    {3 * time.Hour, 0.3, 2},
+				"sport":   {2 * time.Hour, 0.2, 1},
+				"Unknown": {5 * time.Hour, 0.5, 1},
+			},
+		},
+		{
+			disabled: []string{"Unknown"},
+			total:    5 * time.Hour,
+			expect: map[string]expectStat{
+				"work":    {3 * time.Hour, 0.6, 2},
+				"sport":   {2 * time.Hour, 0.4, 1},
+				"Unknown": {5 * time.Hour, 0, 1},
+			},
+		},
+		{
+			disabled: []string{"sport"},
+			total:    8 * time.Hour,
+			expect: map[string]expectStat{
+				"work":    {3 * time.Hour, 0.375, 2},
+				"sport":   {2 * time.Hour, 0, 1},
+				"Unknown": {5 * time.Hour, 0.625, 1},
+			},
+		},
+	}
+	for _, test := range table {
+		res := categoryStats(events, start, end, test.disabled, v1.TagAttribution_ATTRIBUTION_FIRST, "/")
+		if res.Total.AsDuration() != test.total {
+			t.Errorf("disabled %v: expected total %v, got %v", test.disabled, test.total, res.Total.AsDuration())
+		}
+		if res.Tracked.AsDuration() != 6*time.Hour || res.Untracked.AsDuration() != 4*time.Hour {
+			t.Errorf("disabled %v: expected 6h tracked and 4h untracked, got %v and %v", test.disabled, res.Tracked.AsDuration(), res.Untracked.AsDuration())
+		}
+		if len(res.Categories) != len(test.expect) {
+			t.Fatalf("disabled %v: expected %d categories, got %v", test.disabled, len(test.expect), res.Categories)
+		}
+		for i, cat := range res.Categories {
+			if i > 0 && cat.Time.AsDuration() > res.Categories[i-1].Time.AsDuration() {
+				t.Errorf("disabled %v: categories are not sorted by time", test.disabled)
+			}
+			expect, ok := test.expect[cat.Name]
+			if !ok {
+				t.Errorf("disabled %v: unexpected category %s", test.disabled, cat.Name)
+				continue
+			}
+			if cat.Time.AsDuration() != expect.time || math.Abs(cat.Proportion-expect.proportion) > 1e-9 || cat.Events != expect.events {
+				t.Errorf("disabled %v: expected %s to be %+v, got %v", test.disabled, cat.Name, expect, cat)
+			}
+		}
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	stat := func(name string, hours int, disabled bool) *v1.CategoryStat {
+		return &v1.CategoryStat{
+			Name:     name,
+			Time:     durationpb.New(time.Duration(hours) * time.Hour),
+			Disabled: disabled,
+		}
+	}
+	tree := categoryTree([]*v1.CategoryStat{
+		stat("work/meetings/1on1", 2, false),
+		stat("work/meetings", 1, false),
+		stat("work/deep", 4, false),
+		stat("sport", 1, false),
+		stat("work/admin", 5, true),
+		stat("Unknown", 2, false),
+	}, 10*time.Hour, "/")
+
+	// formats the tree as "path self/subtree" lines in order
+	var lines []string
+	var walk func(n *v1.CategoryNode)
+	walk = func(n *v1.CategoryNode) {
+		lines = append(lines, fmt.Sprintf("%s %v/%v", n.Path, n.Self.AsDuration(), n.Subtree.AsDuration()))
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(tree)
+
+	expect := []string{
+		" 0s/10h0m0s",
+		"work 0s/7h0m0s",
+		"work/deep 4h0m0s/4h0m0s",
+		"work/meetings 1h0m0s/3h0m0s",
+		"work/meetings/1on1 2h0m0s/2h0m0s",
+		"Unknown 2h0m0s/2h0m0s",
+		"sport 1h0m0s/1h0m0s",
+	}
+	if !slices.Equal(lines, expect) {
+		t.Fatalf("expected tree\n%s\ngot\n%s", strings.Join(expect, "\n"), strings.Join(lines, "\n"))
+	}
+	if tree.Children[0].Name != "work" || tree.Children[0].Proportion != 0.7 {
+		t.Fatalf("expected 'work' to take 70%%, got %v", tree.Children[0])
+	}
+}
+
+func TestStatsRequiresInterval(t *testing.T) {
+	service := NewCalendarService(&serviceState{})
+	for _, interval := range []*v1.Interval{
+		nil,
+		{Start: timestamppb.Now()},
+		{End: timestamppb.Now()},
+	} {
+		_, err := service.Stats(context.Background(), connect.NewRequest(&v1.StatsRequest{
+			Interval: interval,
+			Timezone: "UTC",
+		}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("%v: expected an invalid argument error, got %v", interval, err)
+		}
+	}
+}
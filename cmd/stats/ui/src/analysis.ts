@@ -51,20 +51,21 @@ export class CategoryStat {
 	time: number;
 	proportion: number;
 	events: Event[];
+	// all-day events shown as background labels, they don't consume time
+	labels: Event[];
 
 	constructor(category: string, time: number, proportion: number) {
 		this.category = category;
 		this.time = time;
 		this.proportion = proportion;
 		this.events = [];
+		this.labels = [];
 	}
 
-	add(e: Event) {
+	// adds the event with the time attributed to this category
+	add(e: Event, seconds: number) {
 		this.events.push(e);
-		if (!e.duration) {
-			throw new Error("undefined duration");
-		}
-		this.time += Number(e.duration.seconds);
+		this.time += seconds;
 	}
 }
 
@@ -96,12 +97,28 @@ export function getCategoryStats(
 		if (!e.duration) {
 			throw new Error("undefined duration");
 		}
-		const tagIdx = e.tags.length > 0 ? e.tags[0] : unknownTagIdx;
+		// the server attributes the duration to the tags of the event
+		const attribution =
+			e.attribution.length > 0
+				? e.attribution.map((a) => ({
+						tagIdx: a.tag,
+						seconds: Number(a.duration?.seconds ?? 0),
+					}))
+				: [{ tagIdx: unknownTagIdx, seconds: Number(e.duration.seconds) }];
+		if (e.background) {
+			const tagIdx = attribution[0].tagIdx;
+			if (!disabledTable[tagIdx]) {
+				categories[tagIdx].labels.push(e);
+			}
+			continue;
+		}
 		trackedSeconds += Number(e.duration.seconds); // add counted seconds regardless of disabled tags
-		if (!disabledTable[tagIdx]) {
-			categories[tagIdx].add(e);
-		} else {
-			disabledSeconds += Number(e.duration.seconds);
+		for (const { tagIdx, seconds } of attribution) {
+			if (!disabledTable[tagIdx]) {
+				categories[tagIdx].add(e, seconds);
+			} else {
+				disabledSeconds += seconds;
+			}
 		}
 	}
 
@@ -126,8 +143,26 @@ export function getCategoryStats(
 		);
 	}
 
-	// sort categories
+	// sort categories, leaving out tags nothing is attributed to like
+	// weighted tags "work:0.7"
 	categories.sort((a, b) => b.time - a.time);
 
-	return categories;
+	return categories.filter(
+		(c) =>
+			c.category === "Unknown" ||
+			c.events.length > 0 ||
+			c.labels.length > 0 ||
+			disabled.includes(c.category),
+	);
+}
+
+// attributedTags returns the tags time can be attributed to.
+export function attributedTags(events: EventsResponse): string[] {
+	const used = new Set<number>();
+	for (const e of events.events) {
+		for (const a of e.attribution) {
+			used.add(a.tag);
+		}
+	}
+	return [...events.tags.filter((_, i) => used.has(i)), "Unknown"];
 }
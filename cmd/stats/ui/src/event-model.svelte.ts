@@ -1,4 +1,10 @@
-import type { EventsResponse } from "$api/api_pb";
+import {
+	OverlapPolicy,
+	TagAttribution,
+	type EventUpdateSchema,
+	type EventsResponse,
+} from "$api/api_pb";
+import type { MessageInitShape } from "@bufbuild/protobuf";
 import { instantToTimestamp } from "$lib/time";
 import { Temporal } from "@js-temporal/polyfill";
 import { toast } from "svelte-sonner";
@@ -19,105 +25,64 @@ export enum IntervalOption {
 	CUSTOM = "CUSTOM",
 }
 
-const full_day = {
-	hours: 23,
-	minutes: 59,
-	seconds: 59,
-	milliseconds: 999,
-	nanoseconds: 999,
-};
+// inclusive returns the interval from start to the end of the given
+// duration, the end is the last nanosecond before it so date pickers show the
+// last day of the interval.
+function inclusive(
+	start: Temporal.ZonedDateTime,
+	duration: Temporal.DurationLike,
+): Interval {
+	return { start, end: start.add(duration).subtract({ nanoseconds: 1 }) };
+}
 
 export class EventModel {
 	option = $state(IntervalOption.THIS_WEEK);
 	customBounds: Interval = $state<Interval>() as Interval;
 	events = $state.raw<EventsResponse>();
+	overlapPolicy = $state(OverlapPolicy.OVERLAP_RAW);
+	// tags in descending priority for OverlapPolicy.OVERLAP_TAG_PRIORITY
+	tagPriority = $state<string[]>([]);
+
+	attribution = $state(TagAttribution.ATTRIBUTION_FIRST);
+
+	overlap = $derived({
+		policy: this.overlapPolicy,
+		tagPriority: this.tagPriority,
+	});
 
 	interval: Interval = $derived.by((): Interval => {
+		// matches report.ResolvePreset, weeks start on monday
 		const now = Temporal.Now.zonedDateTimeISO();
+		const today = now.startOfDay();
+		// like time.AddDate in Go, days past the end of the month overflow
+		// into the next one
+		const monthsAgo = (months: number) =>
+			today
+				.with({ day: 1 })
+				.subtract({ months })
+				.add({ days: today.day - 1 });
 
 		switch (this.option) {
 			case IntervalOption.CUSTOM:
 				return this.customBounds;
-			case IntervalOption.THIS_DAY: {
-				const start = now.subtract({
-					hours: now.hour,
-					minutes: now.minute,
-					seconds: now.second,
-					milliseconds: now.millisecond,
-					nanoseconds: now.nanosecond,
-				});
-				const end = start.add(full_day);
-				return { start, end };
-			}
-			case IntervalOption.THIS_WEEK: {
-				const start = now.subtract({
-					days: now.dayOfWeek,
-					hours: now.hour,
-					minutes: now.minute,
-					seconds: now.second,
-					milliseconds: now.millisecond,
-					nanoseconds: now.nanosecond,
-				});
-				const end = start.add({
-					days: now.daysInWeek - 1,
-					...full_day,
+			case IntervalOption.THIS_DAY:
+				return inclusive(today, { days: 1 });
+			case IntervalOption.THIS_WEEK:
+				return inclusive(today.subtract({ days: now.dayOfWeek - 1 }), {
+					weeks: 1,
 				});
-				return { start, end };
-			}
-			case IntervalOption.THIS_MONTH: {
-				const start = now.subtract({
-					days: now.day - 1,
-					hours: now.hour,
-					minutes: now.minute,
-					seconds: now.second,
-					milliseconds: now.millisecond,
-					nanoseconds: now.nanosecond,
-				});
-				const end = start.add({
+			case IntervalOption.THIS_MONTH:
+				return inclusive(today.with({ day: 1 }).startOfDay(), {
 					months: 1,
-					...full_day,
-				});
-				return { start, end };
-			}
-			case IntervalOption.THIS_YEAR: {
-				const start = now.subtract({
-					days: now.dayOfYear,
-					hours: now.hour,
-					minutes: now.minute,
-					seconds: now.second,
-					milliseconds: now.millisecond,
-					nanoseconds: now.nanosecond,
 				});
-				const end = start.add({
+			case IntervalOption.THIS_YEAR:
+				return inclusive(today.with({ month: 1, day: 1 }).startOfDay(), {
 					years: 1,
-					...full_day,
-				});
-				return { start, end };
-			}
-			case IntervalOption.LAST_3_MONTHS: {
-				const start = now.subtract({
-					months: 3,
-					hours: now.hour,
-					minutes: now.minute,
-					seconds: now.second,
-					milliseconds: now.millisecond,
-					nanoseconds: now.nanosecond,
 				});
-				const end = now;
-				return { start, end };
-			}
-			case IntervalOption.LAST_6_MONTHS: {
-				const start = now.subtract({
-					months: 6,
-					hours: now.hour,
-					minutes: now.minute,
-					seconds: now.second,
-					milliseconds: now.millisecond,
-					nanoseconds: now.nanosecond,
-				});
-				const end = now;
-				return { start, end };
-			}
+			case IntervalOption.LAST_3_MONTHS:
+				return { start: monthsAgo(3), end: now };
+			case IntervalOption.LAST_6_MONTHS:
+				return { start: monthsAgo(6), end: now };
 			default:
 				throw new Error(`unknown option: ${this.option}`);
 		}
@@ -135,6 +100,31 @@ export class EventModel {
 		});
 	}
 
+	private loadCountingOptions() {
+		const policy = Number(localStorage.getItem("overlap.policy"));
+		if (OverlapPolicy[policy] !== undefined) {
+			this.overlapPolicy = policy;
+		}
+		const priority = localStorage.getItem("overlap.tag_priority");
+		if (priority) {
+			try {
+				this.tagPriority = JSON.parse(priority);
+			} catch {}
+		}
+		const attribution = Number(localStorage.getItem("attribution"));
+		if (TagAttribution[attribution] !== undefined) {
+			this.attribution = attribution;
+		}
+		$effect(() => {
+			localStorage.setItem("attribution", this.attribution.toString());
+			localStorage.setItem("overlap.policy", this.overlapPolicy.toString());
+			localStorage.setItem(
+				"overlap.tag_priority",
+				JSON.stringify(this.tagPriority),
+			);
+		});
+	}
+
 	private loadCustomBounds() {
 		const startText = localStorage.getItem("interval.custom.start");
 		const endText = localStorage.getItem("interval.custom.end");
@@ -181,9 +171,12 @@ export class EventModel {
 	constructor() {
 		this.loadOption();
 		this.loadCustomBounds();
+		this.loadCountingOptions();
 
 		$effect(() => {
 			this.interval;
+			this.overlap;
+			this.attribution;
 			this.refresh();
 		});
 	}
@@ -199,6 +192,8 @@ export class EventModel {
 								start: instantToTimestamp(this.interval.start.toInstant()),
 								end: instantToTimestamp(this.interval.end.toInstant()),
 							},
+							overlap: this.overlap,
+							attribution: this.attribution,
 						})
 						.then((res) => {
 							this.events = res;
@@ -238,4 +233,21 @@ export class EventModel {
 			);
 		});
 	}
+
+	async update(
+		events: MessageInitShape<typeof EventUpdateSchema>[],
+	): Promise<void> {
+		const res = await client.updateEvents({ events });
+		const failed = res.results.filter((r) => r.error !== "");
+		for (const r of failed) {
+			toast.error("Update event: Error", {
+				description: r.error,
+				duration: 3000,
+			});
+		}
+		if (failed.length < res.results.length) {
+			toast.success("Update event: Success", { duration: 500 });
+		}
+		await this.refresh();
+	}
 }
@@ -13,3 +13,18 @@ export const calendar = CalendarService.method.calendar;
  * @generated from rpc CalendarService.Events
  */
 export const events = CalendarService.method.events;
+
+/**
+ * @generated from rpc CalendarService.Stats
+ */
+export const stats = CalendarService.method.stats;
+
+/**
+ * @generated from rpc CalendarService.Breakdown
+ */
+export const breakdown = CalendarService.method.breakdown;
+
+/**
+ * @generated from rpc CalendarService.UpdateEvents
+ */
+export const updateEvents = CalendarService.method.updateEvents;
@@ -2,8 +2,8 @@
 // @generated from file v1/api.proto (syntax proto3)
 /* eslint-disable */
 
-import type { GenFile, GenMessage, GenService } from "@bufbuild/protobuf/codegenv1";
-import { fileDesc, messageDesc, serviceDesc } from "@bufbuild/protobuf/codegenv1";
+import type { GenEnum, GenFile, GenMessage, GenService } from "@bufbuild/protobuf/codegenv1";
+import { enumDesc, fileDesc, messageDesc, serviceDesc } from "@bufbuild/protobuf/codegenv1";
 import type { Duration, Timestamp } from "@bufbuild/protobuf/wkt";
 import { file_google_protobuf_duration, file_google_protobuf_timestamp } from "@bufbuild/protobuf/wkt";
 import type { Message } from "@bufbuild/protobuf";
@@ -12,7 +12,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file v1/api.proto.
  */
 export const file_v1_api: GenFile = /*@__PURE__*/
-  fileDesc("Cgx2MS9hcGkucHJvdG8iXgoISW50ZXJ2YWwSKQoFc3RhcnQYASABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEicKA2VuZBgCIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAimgIKBUV2ZW50EgoKAmlkGAEgASgNEgwKBG5hbWUYAiABKA0SEAoIbG9jYXRpb24YAyABKAkSEwoLZGVzY3JpcHRpb24YBCABKAkSDAoEdGFncxgFIAMoDRIbCghpbnRlcnZhbBgGIAEoCzIJLkludGVydmFsEisKCGR1cmF0aW9uGAcgASgLMhkuZ29vZ2xlLnByb3RvYnVmLkR1cmF0aW9uEi0KCHJlbGF0aXZlGAggASgLMhkuZ29vZ2xlLnByb3RvYnVmLkR1cmF0aW9uSAASLgoIYWJzb2x1dGUYCSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wSAASDgoEbm9uZRgKIAEoCEgAQgkKB3RyaWdnZXIiEQoPQ2FsZW5kYXJSZXF1ZXN0Im8KEENhbGVuZGFyUmVzcG9uc2USKQoHc291cmNlcxgBIAMoCzIYLkNhbGVuZGFyUmVzcG9uc2UuU291cmNlGjAKBlNvdXJjZRIXCg9jYWxlbmRhcl9zZXJ2ZXIYASABKAkSDQoFbmFtZXMYAiADKAkiPgoNRXZlbnRzUmVxdWVzdBIbCghpbnRlcnZhbBgBIAEoCzIJLkludGVydmFsEhAKCHRpbWV6b25lGAIgASgJIksKDkV2ZW50c1Jlc3BvbnNlEhMKC2V2ZW50X25hbWVzGAEgAygJEgwKBHRhZ3MYAiADKAkSFgoGZXZlbnRzGAMgAygLMgYuRXZlbnQybQoPQ2FsZW5kYXJTZXJ2aWNlEi8KCENhbGVuZGFyEhAuQ2FsZW5kYXJSZXF1ZXN0GhEuQ2FsZW5kYXJSZXNwb25zZRIpCgZFdmVudHMSDi5FdmVudHNSZXF1ZXN0Gg8uRXZlbnRzUmVzcG9uc2ViBnByb3RvMw", [file_google_protobuf_timestamp, file_google_protobuf_duration]);
+  fileDesc("Cgx2MS9hcGkucHJvdG8iXgoISW50ZXJ2YWwSKQoFc3RhcnQYASABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEicKA2VuZBgCIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAi4gIKBUV2ZW50EgoKAmlkGAEgASgNEgwKBG5hbWUYAiABKA0SEAoIbG9jYXRpb24YAyABKAkSEwoLZGVzY3JpcHRpb24YBCABKAkSDAoEdGFncxgFIAMoDRIbCghpbnRlcnZhbBgGIAEoCzIJLkludGVydmFsEisKCGR1cmF0aW9uGAcgASgLMhkuZ29vZ2xlLnByb3RvYnVmLkR1cmF0aW9uEi0KCHJlbGF0aXZlGAggASgLMhkuZ29vZ2xlLnByb3RvYnVmLkR1cmF0aW9uSAASLgoIYWJzb2x1dGUYCSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wSAASDgoEbm9uZRgKIAEoCEgAEg8KB2FsbF9kYXkYCyABKAgSEgoKYmFja2dyb3VuZBgMIAEoCBIhCgthdHRyaWJ1dGlvbhgNIAMoCzIMLkF0dHJpYnV0aW9uQgkKB3RyaWdnZXIiRwoLQXR0cmlidXRpb24SCwoDdGFnGAEgASgNEisKCGR1cmF0aW9uGAIgASgLMhkuZ29vZ2xlLnByb3RvYnVmLkR1cmF0aW9uIhEKD0NhbGVuZGFyUmVxdWVzdCJvChBDYWxlbmRhclJlc3BvbnNlEikKB3NvdXJjZXMYASADKAsyGC5DYWxlbmRhclJlc3BvbnNlLlNvdXJjZRowCgZTb3VyY2USFwoPY2FsZW5kYXJfc2VydmVyGAEgASgJEg0KBW5hbWVzGAIgAygJIiwKClRleHRGaWx0ZXISDwoHcGF0dGVybhgBIAEoCRINCgVyZWdleBgCIAEoCCI/CgdPdmVybGFwEh4KBnBvbGljeRgBIAEoDjIOLk92ZXJsYXBQb2xpY3kSFAoMdGFnX3ByaW9yaXR5GAIgAygJIsABCg1FdmVudHNSZXF1ZXN0EhsKCGludGVydmFsGAEgASgLMgkuSW50ZXJ2YWwSEAoIdGltZXpvbmUYAiABKAkSHQoIbG9jYXRpb24YAyABKAsyCy5UZXh0RmlsdGVyEiAKC2Rlc2NyaXB0aW9uGAQgASgLMgsuVGV4dEZpbHRlchIZCgdvdmVybGFwGAUgASgLMgguT3ZlcmxhcBIkCgthdHRyaWJ1dGlvbhgGIAEoDjIPLlRhZ0F0dHJpYnV0aW9uIksKDkV2ZW50c1Jlc3BvbnNlEhMKC2V2ZW50X25hbWVzGAEgAygJEgwKBHRhZ3MYAiADKAkSFgoGZXZlbnRzGAMgAygLMgYuRXZlbnQi0QEKDFN0YXRzUmVxdWVzdBIbCghpbnRlcnZhbBgBIAEoCzIJLkludGVydmFsEhAKCHRpbWV6b25lGAIgASgJEh0KCGxvY2F0aW9uGAMgASgLMgsuVGV4dEZpbHRlchIgCgtkZXNjcmlwdGlvbhgEIAEoCzILLlRleHRGaWx0ZXISEAoIZGlzYWJsZWQYBSADKAkSGQoHb3ZlcmxhcBgGIAEoCzIILk92ZXJsYXASJAoLYXR0cmlidXRpb24YByABKA4yDy5UYWdBdHRyaWJ1dGlvbiJ7CgxDYXRlZ29yeVN0YXQSDAoEbmFtZRgBIAEoCRInCgR0aW1lGAIgASgLMhkuZ29vZ2xlLnByb3RvYnVmLkR1cmF0aW9uEhIKCnByb3BvcnRpb24YAyABKAESDgoGZXZlbnRzGAQgASgNEhAKCGRpc2FibGVkGAUgASgIIrQBCgxDYXRlZ29yeU5vZGUSDAoEbmFtZRgBIAEoCRIMCgRwYXRoGAIgASgJEicKBHNlbGYYAyABKAsyGS5nb29nbGUucHJvdG9idWYuRHVyYXRpb24SKgoHc3VidHJlZRgEIAEoCzIZLmdvb2dsZS5wcm90b2J1Zi5EdXJhdGlvbhISCgpwcm9wb3J0aW9uGAUgASgBEh8KCGNoaWxkcmVuGAYgAygLMg0uQ2F0ZWdvcnlOb2RlItMBCg1TdGF0c1Jlc3BvbnNlEiEKCmNhdGVnb3JpZXMYASADKAsyDS5DYXRlZ29yeVN0YXQSKAoFdG90YWwYAiABKAsyGS5nb29nbGUucHJvdG9idWYuRHVyYXRpb24SKgoHdHJhY2tlZBgDIAEoCzIZLmdvb2dsZS5wcm90b2J1Zi5EdXJhdGlvbhIsCgl1bnRyYWNrZWQYBCABKAsyGS5nb29nbGUucHJvdG9idWYuRHVyYXRpb24SGwoEdHJlZRgFIAEoCzINLkNhdGVnb3J5Tm9kZSLlAQoQQnJlYWtkb3duUmVxdWVzdBIbCghpbnRlcnZhbBgBIAEoCzIJLkludGVydmFsEhAKCHRpbWV6b25lGAIgASgJEh0KCGxvY2F0aW9uGAMgASgLMgsuVGV4dEZpbHRlchIgCgtkZXNjcmlwdGlvbhgEIAEoCzILLlRleHRGaWx0ZXISIAoLYnVja2V0X3NpemUYBSABKA4yCy5CdWNrZXRTaXplEhkKB292ZXJsYXAYBiABKAsyCC5PdmVybGFwEiQKC2F0dHJpYnV0aW9uGAcgASgOMg8uVGFnQXR0cmlidXRpb24ifAoGQnVja2V0EhsKCGludGVydmFsGAEgASgLMgkuSW50ZXJ2YWwSJwoEdGltZRgCIAMoCzIZLmdvb2dsZS5wcm90b2J1Zi5EdXJhdGlvbhIsCgl1bnRyYWNrZWQYAyABKAsyGS5nb29nbGUucHJvdG9idWYuRHVyYXRpb24iOwoRQnJlYWtkb3duUmVzcG9uc2USDAoEdGFncxgBIAMoCRIYCgdidWNrZXRzGAIgAygLMgcuQnVja2V0ItECCgtFdmVudFVwZGF0ZRIKCgJpZBgBIAEoDRIRCgRuYW1lGAIgASgJSAGIAQESFQoIbG9jYXRpb24YAyABKAlIAogBARIYCgtkZXNjcmlwdGlvbhgEIAEoCUgDiAEBEh8KBHRhZ3MYBSABKAsyES5FdmVudFVwZGF0ZS5UYWdzEhsKCGludGVydmFsGAYgASgLMgkuSW50ZXJ2YWwSLQoIcmVsYXRpdmUYByABKAsyGS5nb29nbGUucHJvdG9idWYuRHVyYXRpb25IABIuCghhYnNvbHV0ZRgIIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXBIABIOCgRub25lGAkgASgISAAaFAoEVGFncxIMCgR0YWdzGAEgAygJQgkKB3RyaWdnZXJCBwoFX25hbWVCCwoJX2xvY2F0aW9uQg4KDF9kZXNjcmlwdGlvbiIzChNVcGRhdGVFdmVudHNSZXF1ZXN0EhwKBmV2ZW50cxgBIAMoCzIMLkV2ZW50VXBkYXRlImoKFFVwZGF0ZUV2ZW50c1Jlc3BvbnNlEi0KB3Jlc3VsdHMYASADKAsyHC5VcGRhdGVFdmVudHNSZXNwb25zZS5SZXN1bHQaIwoGUmVzdWx0EgoKAmlkGAEgASgNEg0KBWVycm9yGAIgASgJKm0KDlRhZ0F0dHJpYnV0aW9uEhUKEUFUVFJJQlVUSU9OX0ZJUlNUEAASFAoQQVRUUklCVVRJT05fRVZFThABEhgKFEFUVFJJQlVUSU9OX1dFSUdIVEVEEAISFAoQQVRUUklCVVRJT05fRlVMTBADKnoKDU92ZXJsYXBQb2xpY3kSDwoLT1ZFUkxBUF9SQVcQABIVChFPVkVSTEFQX0lOTkVSTU9TVBABEhQKEE9WRVJMQVBfU0hPUlRFU1QQAhIYChRPVkVSTEFQX1RBR19QUklPUklUWRADEhEKDU9WRVJMQVBfU1BMSVQQBCpTCgpCdWNrZXRTaXplEg4KCkJVQ0tFVF9EQVkQABIPCgtCVUNLRVRfV0VFSxABEhAKDEJVQ0tFVF9NT05USBACEhIKDkJVQ0tFVF9RVUFSVEVSEAMyhgIKD0NhbGVuZGFyU2VydmljZRIvCghDYWxlbmRhchIQLkNhbGVuZGFyUmVxdWVzdBoRLkNhbGVuZGFyUmVzcG9uc2USKQoGRXZlbnRzEg4uRXZlbnRzUmVxdWVzdBoPLkV2ZW50c1Jlc3BvbnNlEiYKBVN0YXRzEg0uU3RhdHNSZXF1ZXN0Gg4uU3RhdHNSZXNwb25zZRIyCglCcmVha2Rvd24SES5CcmVha2Rvd25SZXF1ZXN0GhIuQnJlYWtkb3duUmVzcG9uc2USOwoMVXBkYXRlRXZlbnRzEhQuVXBkYXRlRXZlbnRzUmVxdWVzdBoVLlVwZGF0ZUV2ZW50c1Jlc3BvbnNlYgZwcm90bzM", [file_google_protobuf_timestamp, file_google_protobuf_duration]);
 
 /**
  * @generated from message Interval
@@ -101,6 +101,27 @@ export type Event = Message<"Event"> & {
     value: boolean;
     case: "none";
   } | { case: undefined; value?: undefined };
+
+  /**
+   * the event spans whole days instead of starting at a time
+   *
+   * @generated from field: bool all_day = 11;
+   */
+  allDay: boolean;
+
+  /**
+   * the event is a label that doesn't consume time, its duration is zero
+   *
+   * @generated from field: bool background = 12;
+   */
+  background: boolean;
+
+  /**
+   * how the duration is attributed to the tags, empty for untagged events
+   *
+   * @generated from field: repeated Attribution attribution = 13;
+   */
+  attribution: Attribution[];
 };
 
 /**
@@ -110,6 +131,30 @@ export type Event = Message<"Event"> & {
 export const EventSchema: GenMessage<Event> = /*@__PURE__*/
   messageDesc(file_v1_api, 1);
 
+/**
+ * @generated from message Attribution
+ */
+export type Attribution = Message<"Attribution"> & {
+  /**
+   * an index for the lookup table of tag names, weights like "work:0.7" are stripped from the name with the weighted attribution
+   *
+   * @generated from field: uint32 tag = 1;
+   */
+  tag: number;
+
+  /**
+   * @generated from field: google.protobuf.Duration duration = 2;
+   */
+  duration?: Duration;
+};
+
+/**
+ * Describes the message Attribution.
+ * Use `create(AttributionSchema)` to create a new message.
+ */
+export const AttributionSchema: GenMessage<Attribution> = /*@__PURE__*/
+  messageDesc(file_v1_api, 2);
+
 /**
  * Calendar
  *
@@ -123,7 +168,7 @@ export type CalendarRequest = Message<"CalendarRequest"> & {
  * Use `create(CalendarRequestSchema)` to create a new message.
  */
 export const CalendarRequestSchema: GenMessage<CalendarRequest> = /*@__PURE__*/
-  messageDesc(file_v1_api, 2);
+  messageDesc(file_v1_api, 3);
 
 /**
  * @generated from message CalendarResponse
@@ -140,7 +185,7 @@ export type CalendarResponse = Message<"CalendarResponse"> & {
  * Use `create(CalendarResponseSchema)` to create a new message.
  */
 export const CalendarResponseSchema: GenMessage<CalendarResponse> = /*@__PURE__*/
-  messageDesc(file_v1_api, 3);
+  messageDesc(file_v1_api, 4);
 
 /**
  * @generated from message CalendarResponse.Source
@@ -162,11 +207,61 @@ export type CalendarResponse_Source = Message<"CalendarResponse.Source"> & {
  * Use `create(CalendarResponse_SourceSchema)` to create a new message.
  */
 export const CalendarResponse_SourceSchema: GenMessage<CalendarResponse_Source> = /*@__PURE__*/
-  messageDesc(file_v1_api, 3, 0);
+  messageDesc(file_v1_api, 4, 0);
 
 /**
  * Events
  *
+ * @generated from message TextFilter
+ */
+export type TextFilter = Message<"TextFilter"> & {
+  /**
+   * values containing the pattern match, ignoring case
+   *
+   * @generated from field: string pattern = 1;
+   */
+  pattern: string;
+
+  /**
+   * treat the pattern as a regular expression (RE2 syntax) instead
+   *
+   * @generated from field: bool regex = 2;
+   */
+  regex: boolean;
+};
+
+/**
+ * Describes the message TextFilter.
+ * Use `create(TextFilterSchema)` to create a new message.
+ */
+export const TextFilterSchema: GenMessage<TextFilter> = /*@__PURE__*/
+  messageDesc(file_v1_api, 5);
+
+/**
+ * @generated from message Overlap
+ */
+export type Overlap = Message<"Overlap"> & {
+  /**
+   * @generated from field: OverlapPolicy policy = 1;
+   */
+  policy: OverlapPolicy;
+
+  /**
+   * tags in descending priority for OVERLAP_TAG_PRIORITY, unlisted tags rank below them
+   *
+   * @generated from field: repeated string tag_priority = 2;
+   */
+  tagPriority: string[];
+};
+
+/**
+ * Describes the message Overlap.
+ * Use `create(OverlapSchema)` to create a new message.
+ */
+export const OverlapSchema: GenMessage<Overlap> = /*@__PURE__*/
+  messageDesc(file_v1_api, 6);
+
+/**
  * @generated from message EventsRequest
  */
 export type EventsRequest = Message<"EventsRequest"> & {
@@ -179,6 +274,30 @@ export type EventsRequest = Message<"EventsRequest"> & {
    * @generated from field: string timezone = 2;
    */
   timezone: string;
+
+  /**
+   * only events matching every set filter are returned
+   *
+   * @generated from field: TextFilter location = 3;
+   */
+  location?: TextFilter;
+
+  /**
+   * @generated from field: TextFilter description = 4;
+   */
+  description?: TextFilter;
+
+  /**
+   * events are returned as the parts that count towards the statistics, an event may be split into multiple parts sharing its id
+   *
+   * @generated from field: Overlap overlap = 5;
+   */
+  overlap?: Overlap;
+
+  /**
+   * @generated from field: TagAttribution attribution = 6;
+   */
+  attribution: TagAttribution;
 };
 
 /**
@@ -186,7 +305,7 @@ export type EventsRequest = Message<"EventsRequest"> & {
  * Use `create(EventsRequestSchema)` to create a new message.
  */
 export const EventsRequestSchema: GenMessage<EventsRequest> = /*@__PURE__*/
-  messageDesc(file_v1_api, 4);
+  messageDesc(file_v1_api, 7);
 
 /**
  * @generated from message EventsResponse
@@ -213,7 +332,568 @@ export type EventsResponse = Message<"EventsResponse"> & {
  * Use `create(EventsResponseSchema)` to create a new message.
  */
 export const EventsResponseSchema: GenMessage<EventsResponse> = /*@__PURE__*/
-  messageDesc(file_v1_api, 5);
+  messageDesc(file_v1_api, 8);
+
+/**
+ * Stats
+ *
+ * @generated from message StatsRequest
+ */
+export type StatsRequest = Message<"StatsRequest"> & {
+  /**
+   * @generated from field: Interval interval = 1;
+   */
+  interval?: Interval;
+
+  /**
+   * @generated from field: string timezone = 2;
+   */
+  timezone: string;
+
+  /**
+   * only events matching every set filter are counted
+   *
+   * @generated from field: TextFilter location = 3;
+   */
+  location?: TextFilter;
+
+  /**
+   * @generated from field: TextFilter description = 4;
+   */
+  description?: TextFilter;
+
+  /**
+   * categories left out of the totals and proportions, "Unknown" also covers untracked time
+   *
+   * @generated from field: repeated string disabled = 5;
+   */
+  disabled: string[];
+
+  /**
+   * @generated from field: Overlap overlap = 6;
+   */
+  overlap?: Overlap;
+
+  /**
+   * @generated from field: TagAttribution attribution = 7;
+   */
+  attribution: TagAttribution;
+};
+
+/**
+ * Describes the message StatsRequest.
+ * Use `create(StatsRequestSchema)` to create a new message.
+ */
+export const StatsRequestSchema: GenMessage<StatsRequest> = /*@__PURE__*/
+  messageDesc(file_v1_api, 9);
+
+/**
+ * @generated from message CategoryStat
+ */
+export type CategoryStat = Message<"CategoryStat"> & {
+  /**
+   * the tag the time is attributed to, "Unknown" for untagged events and untracked time
+   *
+   * @generated from field: string name = 1;
+   */
+  name: string;
+
+  /**
+   * @generated from field: google.protobuf.Duration time = 2;
+   */
+  time?: Duration;
+
+  /**
+   * the share of the time of all enabled categories
+   *
+   * @generated from field: double proportion = 3;
+   */
+  proportion: number;
+
+  /**
+   * @generated from field: uint32 events = 4;
+   */
+  events: number;
+
+  /**
+   * @generated from field: bool disabled = 5;
+   */
+  disabled: boolean;
+};
+
+/**
+ * Describes the message CategoryStat.
+ * Use `create(CategoryStatSchema)` to create a new message.
+ */
+export const CategoryStatSchema: GenMessage<CategoryStat> = /*@__PURE__*/
+  messageDesc(file_v1_api, 10);
+
+/**
+ * @generated from message CategoryNode
+ */
+export type CategoryNode = Message<"CategoryNode"> & {
+  /**
+   * the last segment of the category path, empty for the root
+   *
+   * @generated from field: string name = 1;
+   */
+  name: string;
+
+  /**
+   * the full category path, as in CategoryStat.name
+   *
+   * @generated from field: string path = 2;
+   */
+  path: string;
+
+  /**
+   * time of the events in exactly this category
+   *
+   * @generated from field: google.protobuf.Duration self = 3;
+   */
+  self?: Duration;
+
+  /**
+   * time of this category and every category below it
+   *
+   * @generated from field: google.protobuf.Duration subtree = 4;
+   */
+  subtree?: Duration;
+
+  /**
+   * the share of the subtree time of all enabled categories
+   *
+   * @generated from field: double proportion = 5;
+   */
+  proportion: number;
+
+  /**
+   * sorted by subtree time, longest first
+   *
+   * @generated from field: repeated CategoryNode children = 6;
+   */
+  children: CategoryNode[];
+};
+
+/**
+ * Describes the message CategoryNode.
+ * Use `create(CategoryNodeSchema)` to create a new message.
+ */
+export const CategoryNodeSchema: GenMessage<CategoryNode> = /*@__PURE__*/
+  messageDesc(file_v1_api, 11);
+
+/**
+ * @generated from message StatsResponse
+ */
+export type StatsResponse = Message<"StatsResponse"> & {
+  /**
+   * sorted by time, longest first
+   *
+   * @generated from field: repeated CategoryStat categories = 1;
+   */
+  categories: CategoryStat[];
+
+  /**
+   * the length of the interval without the time of disabled categories
+   *
+   * @generated from field: google.protobuf.Duration total = 2;
+   */
+  total?: Duration;
+
+  /**
+   * @generated from field: google.protobuf.Duration tracked = 3;
+   */
+  tracked?: Duration;
+
+  /**
+   * @generated from field: google.protobuf.Duration untracked = 4;
+   */
+  untracked?: Duration;
+
+  /**
+   * the enabled categories split into a hierarchy by the configured separator
+   *
+   * @generated from field: CategoryNode tree = 5;
+   */
+  tree?: CategoryNode;
+};
+
+/**
+ * Describes the message StatsResponse.
+ * Use `create(StatsResponseSchema)` to create a new message.
+ */
+export const StatsResponseSchema: GenMessage<StatsResponse> = /*@__PURE__*/
+  messageDesc(file_v1_api, 12);
+
+/**
+ * @generated from message BreakdownRequest
+ */
+export type BreakdownRequest = Message<"BreakdownRequest"> & {
+  /**
+   * @generated from field: Interval interval = 1;
+   */
+  interval?: Interval;
+
+  /**
+   * @generated from field: string timezone = 2;
+   */
+  timezone: string;
+
+  /**
+   * only events matching every set filter are counted
+   *
+   * @generated from field: TextFilter location = 3;
+   */
+  location?: TextFilter;
+
+  /**
+   * @generated from field: TextFilter description = 4;
+   */
+  description?: TextFilter;
+
+  /**
+   * @generated from field: BucketSize bucket_size = 5;
+   */
+  bucketSize: BucketSize;
+
+  /**
+   * @generated from field: Overlap overlap = 6;
+   */
+  overlap?: Overlap;
+
+  /**
+   * @generated from field: TagAttribution attribution = 7;
+   */
+  attribution: TagAttribution;
+};
+
+/**
+ * Describes the message BreakdownRequest.
+ * Use `create(BreakdownRequestSchema)` to create a new message.
+ */
+export const BreakdownRequestSchema: GenMessage<BreakdownRequest> = /*@__PURE__*/
+  messageDesc(file_v1_api, 13);
+
+/**
+ * @generated from message Bucket
+ */
+export type Bucket = Message<"Bucket"> & {
+  /**
+   * the calendar period in the requested timezone, clipped to the requested interval
+   *
+   * @generated from field: Interval interval = 1;
+   */
+  interval?: Interval;
+
+  /**
+   * the time per tag, each element corresponds to the tag with the same index
+   *
+   * @generated from field: repeated google.protobuf.Duration time = 2;
+   */
+  time: Duration[];
+
+  /**
+   * @generated from field: google.protobuf.Duration untracked = 3;
+   */
+  untracked?: Duration;
+};
+
+/**
+ * Describes the message Bucket.
+ * Use `create(BucketSchema)` to create a new message.
+ */
+export const BucketSchema: GenMessage<Bucket> = /*@__PURE__*/
+  messageDesc(file_v1_api, 14);
+
+/**
+ * @generated from message BreakdownResponse
+ */
+export type BreakdownResponse = Message<"BreakdownResponse"> & {
+  /**
+   * the tags the time is attributed to, "Unknown" for untagged events
+   *
+   * @generated from field: repeated string tags = 1;
+   */
+  tags: string[];
+
+  /**
+   * @generated from field: repeated Bucket buckets = 2;
+   */
+  buckets: Bucket[];
+};
+
+/**
+ * Describes the message BreakdownResponse.
+ * Use `create(BreakdownResponseSchema)` to create a new message.
+ */
+export const BreakdownResponseSchema: GenMessage<BreakdownResponse> = /*@__PURE__*/
+  messageDesc(file_v1_api, 15);
+
+/**
+ * UpdateEvents
+ *
+ * @generated from message EventUpdate
+ */
+export type EventUpdate = Message<"EventUpdate"> & {
+  /**
+   * the id of the event as returned by Events
+   *
+   * @generated from field: uint32 id = 1;
+   */
+  id: number;
+
+  /**
+   * the following fields are left unchanged if they are not set
+   *
+   * @generated from field: optional string name = 2;
+   */
+  name?: string;
+
+  /**
+   * @generated from field: optional string location = 3;
+   */
+  location?: string;
+
+  /**
+   * @generated from field: optional string description = 4;
+   */
+  description?: string;
+
+  /**
+   * @generated from field: EventUpdate.Tags tags = 5;
+   */
+  tags?: EventUpdate_Tags;
+
+  /**
+   * @generated from field: Interval interval = 6;
+   */
+  interval?: Interval;
+
+  /**
+   * @generated from oneof EventUpdate.trigger
+   */
+  trigger: {
+    /**
+     * @generated from field: google.protobuf.Duration relative = 7;
+     */
+    value: Duration;
+    case: "relative";
+  } | {
+    /**
+     * @generated from field: google.protobuf.Timestamp absolute = 8;
+     */
+    value: Timestamp;
+    case: "absolute";
+  } | {
+    /**
+     * @generated from field: bool none = 9;
+     */
+    value: boolean;
+    case: "none";
+  } | { case: undefined; value?: undefined };
+};
+
+/**
+ * Describes the message EventUpdate.
+ * Use `create(EventUpdateSchema)` to create a new message.
+ */
+export const EventUpdateSchema: GenMessage<EventUpdate> = /*@__PURE__*/
+  messageDesc(file_v1_api, 16);
+
+/**
+ * @generated from message EventUpdate.Tags
+ */
+export type EventUpdate_Tags = Message<"EventUpdate.Tags"> & {
+  /**
+   * @generated from field: repeated string tags = 1;
+   */
+  tags: string[];
+};
+
+/**
+ * Describes the message EventUpdate.Tags.
+ * Use `create(EventUpdate_TagsSchema)` to create a new message.
+ */
+export const EventUpdate_TagsSchema: GenMessage<EventUpdate_Tags> = /*@__PURE__*/
+  messageDesc(file_v1_api, 16, 0);
+
+/**
+ * @generated from message UpdateEventsRequest
+ */
+export type UpdateEventsRequest = Message<"UpdateEventsRequest"> & {
+  /**
+   * @generated from field: repeated EventUpdate events = 1;
+   */
+  events: EventUpdate[];
+};
+
+/**
+ * Describes the message UpdateEventsRequest.
+ * Use `create(UpdateEventsRequestSchema)` to create a new message.
+ */
+export const UpdateEventsRequestSchema: GenMessage<UpdateEventsRequest> = /*@__PURE__*/
+  messageDesc(file_v1_api, 17);
+
+/**
+ * @generated from message UpdateEventsResponse
+ */
+export type UpdateEventsResponse = Message<"UpdateEventsResponse"> & {
+  /**
+   * @generated from field: repeated UpdateEventsResponse.Result results = 1;
+   */
+  results: UpdateEventsResponse_Result[];
+};
+
+/**
+ * Describes the message UpdateEventsResponse.
+ * Use `create(UpdateEventsResponseSchema)` to create a new message.
+ */
+export const UpdateEventsResponseSchema: GenMessage<UpdateEventsResponse> = /*@__PURE__*/
+  messageDesc(file_v1_api, 18);
+
+/**
+ * @generated from message UpdateEventsResponse.Result
+ */
+export type UpdateEventsResponse_Result = Message<"UpdateEventsResponse.Result"> & {
+  /**
+   * @generated from field: uint32 id = 1;
+   */
+  id: number;
+
+  /**
+   * error is empty if the update succeeded
+   *
+   * @generated from field: string error = 2;
+   */
+  error: string;
+};
+
+/**
+ * Describes the message UpdateEventsResponse.Result.
+ * Use `create(UpdateEventsResponse_ResultSchema)` to create a new message.
+ */
+export const UpdateEventsResponse_ResultSchema: GenMessage<UpdateEventsResponse_Result> = /*@__PURE__*/
+  messageDesc(file_v1_api, 18, 0);
+
+/**
+ * @generated from enum TagAttribution
+ */
+export enum TagAttribution {
+  /**
+   * the first tag takes the whole duration
+   *
+   * @generated from enum value: ATTRIBUTION_FIRST = 0;
+   */
+  ATTRIBUTION_FIRST = 0,
+
+  /**
+   * the duration is split evenly between the tags
+   *
+   * @generated from enum value: ATTRIBUTION_EVEN = 1;
+   */
+  ATTRIBUTION_EVEN = 1,
+
+  /**
+   * tags are weighted like "work:0.7" with a weight between 0 and 1, tags without a weight split the rest evenly
+   *
+   * @generated from enum value: ATTRIBUTION_WEIGHTED = 2;
+   */
+  ATTRIBUTION_WEIGHTED = 2,
+
+  /**
+   * every tag takes the whole duration, so the time of all categories can exceed the tracked time
+   *
+   * @generated from enum value: ATTRIBUTION_FULL = 3;
+   */
+  ATTRIBUTION_FULL = 3,
+}
+
+/**
+ * Describes the enum TagAttribution.
+ */
+export const TagAttributionSchema: GenEnum<TagAttribution> = /*@__PURE__*/
+  enumDesc(file_v1_api, 0);
+
+/**
+ * @generated from enum OverlapPolicy
+ */
+export enum OverlapPolicy {
+  /**
+   * overlapping events are all counted in full
+   *
+   * @generated from enum value: OVERLAP_RAW = 0;
+   */
+  OVERLAP_RAW = 0,
+
+  /**
+   * the event that started last takes the overlapping time
+   *
+   * @generated from enum value: OVERLAP_INNERMOST = 1;
+   */
+  OVERLAP_INNERMOST = 1,
+
+  /**
+   * the shortest event takes the overlapping time
+   *
+   * @generated from enum value: OVERLAP_SHORTEST = 2;
+   */
+  OVERLAP_SHORTEST = 2,
+
+  /**
+   * the event whose first tag comes first in the tag priority takes the overlapping time
+   *
+   * @generated from enum value: OVERLAP_TAG_PRIORITY = 3;
+   */
+  OVERLAP_TAG_PRIORITY = 3,
+
+  /**
+   * the overlapping time is split evenly between the events
+   *
+   * @generated from enum value: OVERLAP_SPLIT = 4;
+   */
+  OVERLAP_SPLIT = 4,
+}
+
+/**
+ * Describes the enum OverlapPolicy.
+ */
+export const OverlapPolicySchema: GenEnum<OverlapPolicy> = /*@__PURE__*/
+  enumDesc(file_v1_api, 1);
+
+/**
+ * Breakdown
+ *
+ * @generated from enum BucketSize
+ */
+export enum BucketSize {
+  /**
+   * @generated from enum value: BUCKET_DAY = 0;
+   */
+  BUCKET_DAY = 0,
+
+  /**
+   * weeks start on monday, as in ISO 8601
+   *
+   * @generated from enum value: BUCKET_WEEK = 1;
+   */
+  BUCKET_WEEK = 1,
+
+  /**
+   * @generated from enum value: BUCKET_MONTH = 2;
+   */
+  BUCKET_MONTH = 2,
+
+  /**
+   * @generated from enum value: BUCKET_QUARTER = 3;
+   */
+  BUCKET_QUARTER = 3,
+}
+
+/**
+ * Describes the enum BucketSize.
+ */
+export const BucketSizeSchema: GenEnum<BucketSize> = /*@__PURE__*/
+  enumDesc(file_v1_api, 2);
 
 /**
  * @generated from service CalendarService
@@ -235,6 +915,30 @@ export const CalendarService: GenService<{
     input: typeof EventsRequestSchema;
     output: typeof EventsResponseSchema;
   },
+  /**
+   * @generated from rpc CalendarService.Stats
+   */
+  stats: {
+    methodKind: "unary";
+    input: typeof StatsRequestSchema;
+    output: typeof StatsResponseSchema;
+  },
+  /**
+   * @generated from rpc CalendarService.Breakdown
+   */
+  breakdown: {
+    methodKind: "unary";
+    input: typeof BreakdownRequestSchema;
+    output: typeof BreakdownResponseSchema;
+  },
+  /**
+   * @generated from rpc CalendarService.UpdateEvents
+   */
+  updateEvents: {
+    methodKind: "unary";
+    input: typeof UpdateEventsRequestSchema;
+    output: typeof UpdateEventsResponseSchema;
+  },
 }> = /*@__PURE__*/
   serviceDesc(file_v1_api, 0);
 
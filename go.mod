@@ -8,29 +8,28 @@ require (
 	github.com/emersion/go-ical v0.0.0-20240127095438-fc1c9d8fb2b6
 	github.com/emersion/go-webdav v0.6.0
 	github.com/fsnotify/fsnotify v1.9.0
+	github.com/hujun-open/myflags/v2 v2.0.3
 	github.com/hujun-open/shouchan/v2 v2.0.2
 	github.com/lmittmann/tint v1.0.7
 	github.com/rs/cors v1.11.1
 	github.com/teambition/rrule-go v1.8.2
 	github.com/titanous/json5 v1.0.0
+	github.com/yuin/gopher-lua v1.1.1
 	github.com/zeebo/xxh3 v1.0.2
 	golang.org/x/net v0.23.0
 	google.golang.org/protobuf v1.36.6
 )
 
 require (
-	github.com/ainvaltin/nu-plugin v0.0.0-20250726091949-d237018ffe59 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.6 // indirect
+	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/hujun-open/cobra v0.1.0 // indirect
 	github.com/hujun-open/extyaml v0.5.3 // indirect
-	github.com/hujun-open/myflags/v2 v2.0.3 // indirect
 	github.com/hujun-open/pflag v0.2.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
-	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
\ No newline at end of file
+)
@@ -0,0 +1,401 @@
+// Package bulk implements bulk editing of calendar events with lua scripts.
+//
+// See docs/BULK_EDITING.md for the interface exposed to scripts.
+package bulk
+
+import (
+	"calstats/internal/calendar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Entry is an event that can be edited by a script.
+type Entry struct {
+	Source calendar.Source
+	// SourceIndex identifies the source, the changes to each source are
+	// committed together. Sources can't be compared themselves, as they hold
+	// maps.
+	SourceIndex int
+	Calendar    calendar.Calendar
+	Event       calendar.Event
+}
+
+// Change is an edit made by a script to an entry.
+type Change struct {
+	Entry
+	Update calendar.UpdateEvent
+}
+
+// Run executes the lua script with the global `events` set to the given
+// entries and returns the changes the script made to them. Datetimes are
+// exposed to the script in the given timezone.
+func Run(script string, entries []Entry, tz *time.Location) (changes []Change, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("run script: %w", err)
+		}
+	}()
+
+	L := lua.NewState()
+	defer L.Close()
+
+	events := L.NewTable()
+	for i, e := range entries {
+		events.Append(eventToTable(L, i, e, tz))
+	}
+	L.SetGlobal("events", events)
+
+	err = L.DoString(script)
+	if err != nil {
+		return
+	}
+
+	result, ok := L.GetGlobal("events").(*lua.LTable)
+	if !ok {
+		err = fmt.Errorf("global 'events' is not a table")
+		return
+	}
+
+	seen := make([]bool, len(entries))
+	var errs []error
+	result.ForEach(func(key, value lua.LValue) {
+		table, ok := value.(*lua.LTable)
+		if !ok {
+			errs = append(errs, fmt.Errorf("events[%s]: not a table", key))
+			return
+		}
+		idx, ok := table.RawGetString("id").(lua.LNumber)
+		if !ok || float64(idx) != math.Trunc(float64(idx)) || int(idx) < 0 || int(idx) >= len(entries) {
+			errs = append(errs, fmt.Errorf("events[%s]: missing or invalid id, new events cannot be created", key))
+			return
+		}
+		if seen[int(idx)] {
+			errs = append(errs, fmt.Errorf("events[%s]: id %d is used by several events, events cannot be duplicated", key, int(idx)))
+			return
+		}
+		seen[int(idx)] = true
+
+		entry := entries[int(idx)]
+		update, changed, err := diffEvent(entry.Event, table, tz)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("events[%s] (%s): %w", key, entry.Event.Name, err))
+			return
+		}
+		if changed {
+			changes = append(changes, Change{Entry: entry, Update: update})
+		}
+	})
+	for i, ok := range seen {
+		if !ok {
+			errs = append(errs, fmt.Errorf("event '%s' was removed, events cannot be deleted", entries[i].Event.Name))
+		}
+	}
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		return a.Event.Start.Compare(b.Event.Start)
+	})
+	return
+}
+
+// Commit writes the changes to their sources.
+func Commit(ctx context.Context, changes []Change) error {
+	type group struct {
+		source  calendar.Source
+		updates []calendar.UpdateEvent
+	}
+	var groups []*group
+	bySource := map[int]*group{}
+	for _, c := range changes {
+		g, ok := bySource[c.SourceIndex]
+		if !ok {
+			g = &group{source: c.Source}
+			bySource[c.SourceIndex] = g
+			groups = append(groups, g)
+		}
+		g.updates = append(g.updates, c.Update)
+	}
+
+	var errs []error
+	for _, g := range groups {
+		err := g.source.Update(ctx, g.updates)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PrintDiff writes a human readable diff of the changes to w.
+func PrintDiff(w io.Writer, changes []Change, tz *time.Location) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+
+	for _, c := range changes {
+		e := c.Event
+		u := c.Update
+		fmt.Fprintf(w, "~ %s [%s] (%s)\n", e.Name, c.Calendar.Name, e.Start.In(tz).Format(time.DateTime))
+		if u.Name != nil {
+			fmt.Fprintf(w, "    name: %q -> %q\n", e.Name, *u.Name)
+		}
+		if u.Location != nil {
+			fmt.Fprintf(w, "    location: %q -> %q\n", e.Location, *u.Location)
+		}
+		if u.Description != nil {
+			fmt.Fprintf(w, "    description: %q -> %q\n", e.Description, *u.Description)
+		}
+		if u.Tags != nil {
+			fmt.Fprintf(w, "    tags: [%s] -> [%s]\n", strings.Join(e.Tags, ", "), strings.Join(*u.Tags, ", "))
+		}
+		if u.Start != nil {
+			fmt.Fprintf(w, "    start: %s -> %s\n", e.Start.In(tz).Format(time.DateTime), u.Start.In(tz).Format(time.DateTime))
+		}
+		if u.End != nil {
+			fmt.Fprintf(w, "    end: %s -> %s\n", e.End.In(tz).Format(time.DateTime), u.End.In(tz).Format(time.DateTime))
+		}
+		if u.Trigger != nil {
+			fmt.Fprintf(w, "    reminder: %s -> %s\n", formatTrigger(e.Trigger, tz), formatTrigger(*u.Trigger, tz))
+		}
+	}
+	fmt.Fprintf(w, "\n%d event(s) changed\n", len(changes))
+}
+
+func formatTrigger(t calendar.EventTrigger, tz *time.Location) string {
+	if !t.Absolute.IsZero() {
+		return t.Absolute.In(tz).Format(time.DateTime)
+	}
+	if t.NotNone {
+		return t.Relative.String()
+	}
+	return "none"
+}
+
+func eventToTable(L *lua.LState, idx int, e Entry, tz *time.Location) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString("id", lua.LNumber(idx))
+	table.RawSetString("calendar", lua.LString(e.Calendar.Name))
+	table.RawSetString("name", lua.LString(e.Event.Name))
+	table.RawSetString("location", lua.LString(e.Event.Location))
+	table.RawSetString("description", lua.LString(e.Event.Description))
+
+	tags := L.NewTable()
+	for _, t := range e.Event.Tags {
+		tags.Append(lua.LString(t))
+	}
+	table.RawSetString("tags", tags)
+
+	table.RawSetString("start", dateTimeToTable(L, e.Event.Start.In(tz)))
+	table.RawSetString("end", dateTimeToTable(L, e.Event.End.In(tz)))
+
+	if !e.Event.Trigger.Absolute.IsZero() {
+		reminder := L.NewTable()
+		reminder.RawSetString("absolute", dateTimeToTable(L, e.Event.Trigger.Absolute.In(tz)))
+		table.RawSetString("reminder", reminder)
+	} else if e.Event.Trigger.NotNone {
+		reminder := L.NewTable()
+		reminder.RawSetString("relative", lua.LNumber(e.Event.Trigger.Relative.Seconds()))
+		table.RawSetString("reminder", reminder)
+	}
+	return table
+}
+
+// dateTimeToTable converts a time into a table in the same format as
+// os.date("*t").
+func dateTimeToTable(L *lua.LState, t time.Time) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString("year", lua.LNumber(t.Year()))
+	table.RawSetString("month", lua.LNumber(t.Month()))
+	table.RawSetString("day", lua.LNumber(t.Day()))
+	table.RawSetString("hour", lua.LNumber(t.Hour()))
+	table.RawSetString("min", lua.LNumber(t.Minute()))
+	table.RawSetString("sec", lua.LNumber(t.Second()))
+	table.RawSetString("wday", lua.LNumber(t.Weekday()+1))
+	table.RawSetString("yday", lua.LNumber(t.YearDay()))
+	return table
+}
+
+// tableToDateTime converts a DateTime value into a time. The value can be a
+// table in the same format as os.date("*t") or a unix timestamp.
+func tableToDateTime(value lua.LValue, tz *time.Location) (time.Time, error) {
+	switch v := value.(type) {
+	case lua.LNumber:
+		return time.Unix(int64(v), 0).In(tz), nil
+	case *lua.LTable:
+		field := func(name string, def int) (int, error) {
+			switch f := v.RawGetString(name).(type) {
+			case lua.LNumber:
+				return int(f), nil
+			case *lua.LNilType:
+				if def < 0 {
+					return 0, fmt.Errorf("missing field '%s'", name)
+				}
+				return def, nil
+			default:
+				return 0, fmt.Errorf("field '%s' is not a number", name)
+			}
+		}
+		var parts [6]int
+		var err error
+		for i, name := range []string{"year", "month", "day", "hour", "min", "sec"} {
+			def := -1
+			if i >= 3 {
+				def = 0
+			}
+			parts[i], err = field(name, def)
+			if err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, tz), nil
+	}
+	return time.Time{}, fmt.Errorf("expected table or number, got %s", value.Type())
+}
+
+func stringField(table *lua.LTable, name string) (string, error) {
+	switch v := table.RawGetString(name).(type) {
+	case lua.LString:
+		return string(v), nil
+	case *lua.LNilType:
+		return "", nil
+	default:
+		return "", fmt.Errorf("field '%s' is not a string", name)
+	}
+}
+
+// diffEvent compares the table modified by the script to the original event
+// and returns an update containing the fields that changed.
+func diffEvent(e calendar.Event, table *lua.LTable, tz *time.Location) (update calendar.UpdateEvent, changed bool, err error) {
+	update.Id = e.Id
+
+	name, err := stringField(table, "name")
+	if err != nil {
+		return
+	}
+	if name != e.Name {
+		update.Name = &name
+		changed = true
+	}
+	location, err := stringField(table, "location")
+	if err != nil {
+		return
+	}
+	if location != e.Location {
+		update.Location = &location
+		changed = true
+	}
+	description, err := stringField(table, "description")
+	if err != nil {
+		return
+	}
+	if description != e.Description {
+		update.Description = &description
+		changed = true
+	}
+
+	var tags []string
+	switch v := table.RawGetString("tags").(type) {
+	case *lua.LTable:
+		v.ForEach(func(_, tag lua.LValue) {
+			if err != nil {
+				return
+			}
+			s, ok := tag.(lua.LString)
+			if !ok {
+				err = fmt.Errorf("field 'tags' contains a %s", tag.Type())
+				return
+			}
+			tags = append(tags, string(s))
+		})
+		if err != nil {
+			return
+		}
+	case *lua.LNilType:
+	default:
+		err = fmt.Errorf("field 'tags' is not a table")
+		return
+	}
+	if !slices.Equal(tags, e.Tags) {
+		update.Tags = &tags
+		changed = true
+	}
+
+	start, err := tableToDateTime(table.RawGetString("start"), tz)
+	if err != nil {
+		err = fmt.Errorf("field 'start': %w", err)
+		return
+	}
+	if !start.Equal(e.Start.Truncate(time.Second)) {
+		update.Start = &start
+		changed = true
+	}
+	end, err := tableToDateTime(table.RawGetString("end"), tz)
+	if err != nil {
+		err = fmt.Errorf("field 'end': %w", err)
+		return
+	}
+	if !end.Equal(e.End.Truncate(time.Second)) {
+		update.End = &end
+		changed = true
+	}
+	// the start or end of a cropped event is a bound of the loaded interval,
+	// writing it back would shorten the event
+	if e.Cropped && (update.Start != nil || update.End != nil) {
+		err = fmt.Errorf("the event extends beyond the loaded interval, load an interval that covers it to change its start or end")
+		return
+	}
+
+	var trigger calendar.EventTrigger
+	switch v := table.RawGetString("reminder").(type) {
+	case *lua.LTable:
+		switch rel := v.RawGetString("relative").(type) {
+		case lua.LNumber:
+			trigger.Relative = time.Duration(float64(rel) * float64(time.Second))
+			trigger.NotNone = true
+		case *lua.LNilType:
+		default:
+			err = fmt.Errorf("field 'reminder.relative' is not a number")
+			return
+		}
+		if abs := v.RawGetString("absolute"); abs != lua.LNil {
+			trigger.Absolute, err = tableToDateTime(abs, tz)
+			if err != nil {
+				err = fmt.Errorf("field 'reminder.absolute': %w", err)
+				return
+			}
+			trigger.Relative = 0
+			trigger.NotNone = true
+		}
+	case *lua.LNilType:
+	default:
+		err = fmt.Errorf("field 'reminder' is not a table")
+		return
+	}
+	if !triggerEqual(trigger, e.Trigger) {
+		update.Trigger = &trigger
+		changed = true
+	}
+	return
+}
+
+func triggerEqual(a, b calendar.EventTrigger) bool {
+	if !a.Absolute.IsZero() || !b.Absolute.IsZero() {
+		return a.Absolute.Truncate(time.Second).Equal(b.Absolute.Truncate(time.Second))
+	}
+	if a.NotNone != b.NotNone {
+		return false
+	}
+	return !a.NotNone || a.Relative == b.Relative
+}
@@ -0,0 +1,174 @@
+package bulk
+
+import (
+	"calstats/internal/calendar"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	tz := time.UTC
+	entries := []Entry{
+		{
+			Calendar: calendar.Calendar{Name: "work"},
+			Event: calendar.Event{
+				Id:    1,
+				Name:  "1:1 with Sam",
+				Start: time.Date(2025, time.January, 6, 9, 0, 0, 0, tz),
+				End:   time.Date(2025, time.January, 6, 9, 30, 0, 0, tz),
+			},
+		},
+		{
+			Calendar: calendar.Calendar{Name: "work"},
+			Event: calendar.Event{
+				Id:    2,
+				Name:  "Deep work",
+				Tags:  []string{"work"},
+				Start: time.Date(2025, time.January, 6, 10, 0, 0, 0, tz),
+				End:   time.Date(2025, time.January, 6, 12, 0, 0, 0, tz),
+				Trigger: calendar.EventTrigger{
+					Relative: -15 * time.Minute,
+					NotNone:  true,
+				},
+			},
+		},
+	}
+
+	changes, err := Run(`
+for _, e in ipairs(events) do
+	if e.name:find("1:1") then
+		e.tags = { "work", "meetings" }
+		e["end"].min = 45
+	end
+	if e.name == "Deep work" then
+		e.reminder = nil
+	end
+end
+`, entries, tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	oneOnOne := changes[0].Update
+	if oneOnOne.Id != 1 {
+		t.Fatalf("expected change to event 1, got %d", oneOnOne.Id)
+	}
+	if oneOnOne.Tags == nil || !slices.Equal(*oneOnOne.Tags, []string{"work", "meetings"}) {
+		t.Fatalf("unexpected tags: %v", oneOnOne.Tags)
+	}
+	if oneOnOne.End == nil || !oneOnOne.End.Equal(time.Date(2025, time.January, 6, 9, 45, 0, 0, tz)) {
+		t.Fatalf("unexpected end: %v", oneOnOne.End)
+	}
+	if oneOnOne.Name != nil || oneOnOne.Start != nil || oneOnOne.Trigger != nil {
+		t.Fatalf("unchanged fields should not be updated: %+v", oneOnOne)
+	}
+
+	deepWork := changes[1].Update
+	if deepWork.Trigger == nil || deepWork.Trigger.NotNone {
+		t.Fatalf("expected reminder to be removed, got %+v", deepWork.Trigger)
+	}
+	if deepWork.Tags != nil {
+		t.Fatalf("tags should not be updated: %v", *deepWork.Tags)
+	}
+}
+
+func TestRunRejectsRemovedEvents(t *testing.T) {
+	entries := []Entry{{Event: calendar.Event{Name: "A"}}}
+	_, err := Run(`events = {}`, entries, time.UTC)
+	if err == nil {
+		t.Fatal("expected an error when events are removed")
+	}
+}
+
+func TestRunRejectsInvalidIds(t *testing.T) {
+	entries := []Entry{{Event: calendar.Event{Name: "A"}}, {Event: calendar.Event{Name: "B"}}}
+	for _, script := range []string{
+		`events[2].id = 1.5`,
+		`events[3] = events[1]`,
+	} {
+		if _, err := Run(script, entries, time.UTC); err == nil {
+			t.Errorf("%s: expected an error", script)
+		}
+	}
+}
+
+func TestRunRejectsMovingCroppedEvents(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2025, time.January, 6, hour, 0, 0, 0, time.UTC)
+	}
+	entries := []Entry{{Event: calendar.Event{Name: "Offsite", Start: at(0), End: at(12), Cropped: true}}}
+
+	changes, err := Run(`events[1].name = "Retreat"`, entries, time.UTC)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("expected renaming a cropped event to work, got %v, %v", changes, err)
+	}
+	_, err = Run(`events[1]["end"].hour = 10`, entries, time.UTC)
+	if err == nil {
+		t.Fatal("expected an error when the end of a cropped event changes")
+	}
+}
+
+func TestCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.ics")
+	err := os.WriteFile(path, []byte(strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//calstats//test//EN",
+		"BEGIN:VEVENT",
+		"UID:review",
+		"DTSTAMP:20250101T000000Z",
+		"SUMMARY:Review",
+		"DTSTART:20250106T090000Z",
+		"DTEND:20250106T100000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	source, err := calendar.NewLocal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cals, err := source.Calendars(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, time.January, 6, 0, 0, 0, 0, time.UTC)
+	events, err := source.Events(ctx, cals[0], start, start.AddDate(0, 0, 1), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []Entry
+	for _, e := range events {
+		entries = append(entries, Entry{Source: source, Calendar: cals[0], Event: e})
+	}
+
+	changes, err := Run(`events[1].name = "Code review"`, entries, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Commit(ctx, changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "SUMMARY:Code review") {
+		t.Fatalf("expected the file to be rewritten, got\n%s", data)
+	}
+}
@@ -0,0 +1,252 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/zeebo/xxh3"
+)
+
+// caldavCache stores the calendar objects of a caldav server on disk, so that
+// calendars only have to be synced incrementally and remain available when
+// the server cannot be reached.
+type caldavCache struct {
+	dir string
+
+	// mutex guards calendars and locks, it is only held to access them.
+	mutex     sync.Mutex
+	calendars map[string]*cachedCalendar
+	// locks holds a lock per calendar, which is held while the calendar is
+	// loaded, saved or its objects are parsed. It isn't held while talking
+	// to the server, so calendars are synced concurrently.
+	locks map[string]*sync.Mutex
+}
+
+type cachedCalendar struct {
+	// SyncToken is the token returned by the last sync-collection report, it
+	// is empty if the calendar has not been synced with sync-collection.
+	SyncToken string `json:"sync_token"`
+	// NoSyncCollection is true if the server does not support sync-collection
+	// for the calendar, changes are detected by comparing etags instead.
+	NoSyncCollection bool `json:"no_sync_collection"`
+	// Objects contains the calendar objects keyed by their path.
+	Objects map[string]*cachedObject `json:"objects"`
+}
+
+type cachedObject struct {
+	ETag string `json:"etag"`
+	Data string `json:"data"`
+
+	parsed *ical.Calendar
+	// span caches the result of span, spanDone is true once it is computed
+	spanStart, spanEnd time.Time
+	spanOk, spanDone   bool
+}
+
+func newCaldavCache(dir string) (*caldavCache, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &caldavCache{
+		dir:       dir,
+		calendars: map[string]*cachedCalendar{},
+		locks:     map[string]*sync.Mutex{},
+	}, nil
+}
+
+// calendarLock returns the lock of the calendar with the given id.
+func (c *caldavCache) calendarLock(id string) *sync.Mutex {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	lock, ok := c.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[id] = lock
+	}
+	return lock
+}
+
+// cacheDirFor returns the directory the objects of a server are cached in.
+func cacheDirFor(root, server, username string) string {
+	return filepath.Join(root, fmt.Sprintf("%016x", xxh3.HashString(server+"\x00"+username)))
+}
+
+func (c *caldavCache) calendarFile(id string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%016x.json", xxh3.HashString(id)))
+}
+
+// load returns the cached calendar with the given id, it returns an empty
+// calendar if nothing has been cached yet. The lock of the calendar must be
+// held. The returned calendar must not be modified, sync replaces it instead.
+func (c *caldavCache) load(id string) (*cachedCalendar, error) {
+	c.mutex.Lock()
+	cal, ok := c.calendars[id]
+	c.mutex.Unlock()
+	if ok {
+		return cal, nil
+	}
+
+	cal = &cachedCalendar{}
+	contents, err := os.ReadFile(c.calendarFile(id))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read cache: %w", err)
+	}
+	if err == nil {
+		err = json.Unmarshal(contents, cal)
+		if err != nil {
+			return nil, fmt.Errorf("read cache: %w", err)
+		}
+	}
+	if cal.Objects == nil {
+		cal.Objects = map[string]*cachedObject{}
+	}
+	c.mutex.Lock()
+	c.calendars[id] = cal
+	c.mutex.Unlock()
+	return cal, nil
+}
+
+// save writes the cached calendar to disk. The lock of the calendar must be
+// held.
+func (c *caldavCache) save(id string, cal *cachedCalendar) error {
+	c.mutex.Lock()
+	c.calendars[id] = cal
+	c.mutex.Unlock()
+	contents, err := json.Marshal(cal)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.calendarFile(id), contents)
+}
+
+func (c *caldavCache) calendarsFile() string {
+	return filepath.Join(c.dir, "calendars.json")
+}
+
+func (c *caldavCache) loadCalendars() ([]Calendar, error) {
+	contents, err := os.ReadFile(c.calendarsFile())
+	if err != nil {
+		return nil, err
+	}
+	var cals []Calendar
+	err = json.Unmarshal(contents, &cals)
+	return cals, err
+}
+
+func (c *caldavCache) saveCalendars(cals []Calendar) error {
+	contents, err := json.Marshal(cals)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.calendarsFile(), contents)
+}
+
+// calendar returns the parsed calendar object.
+func (o *cachedObject) calendar() (*ical.Calendar, error) {
+	if o.parsed != nil {
+		return o.parsed, nil
+	}
+	cal, err := ical.NewDecoder(strings.NewReader(o.Data)).Decode()
+	if err != nil {
+		return nil, err
+	}
+	o.parsed = cal
+	return cal, nil
+}
+
+// spanMargin widens the span of objects, so that floating times and dates,
+// which depend on the timezone of the request, are covered in every timezone.
+const spanMargin = 24 * time.Hour
+
+// span returns the time covered by the events of the object, ok is false if
+// it can't be bounded, like for recurring events or objects that can't be
+// parsed. The lock of the calendar must be held.
+func (o *cachedObject) span() (start, end time.Time, ok bool) {
+	if o.spanDone {
+		return o.spanStart, o.spanEnd, o.spanOk
+	}
+	o.spanDone = true
+
+	cal, err := o.calendar()
+	if err != nil {
+		return
+	}
+	tzr := newTzResolver(cal)
+	for i, e := range cal.Events() {
+		for _, name := range []string{ical.PropRecurrenceRule, ical.PropRecurrenceDates, ical.PropRecurrenceID} {
+			if e.Props.Get(name) != nil {
+				return
+			}
+		}
+		eventStart, err := tzr.eventStart(e, time.UTC)
+		if err != nil {
+			return
+		}
+		eventEnd, err := tzr.eventEnd(e, time.UTC)
+		if err != nil {
+			return
+		}
+		if i == 0 || eventStart.Before(start) {
+			start = eventStart
+		}
+		if i == 0 || eventEnd.After(end) {
+			end = eventEnd
+		}
+	}
+	o.spanStart, o.spanEnd, o.spanOk = start.Add(-spanMargin), end.Add(spanMargin), true
+	return o.spanStart, o.spanEnd, o.spanOk
+}
+
+// version identifies the contents of the object, it is the etag or a hash of
+// the data for servers that don't send etags.
+func (o *cachedObject) version() string {
+	if o.ETag != "" {
+		return o.ETag
+	}
+	return fmt.Sprintf("%016x", xxh3.HashString(o.Data))
+}
+
+func newCachedObject(etag string, cal *ical.Calendar) (*cachedObject, error) {
+	var buf bytes.Buffer
+	err := ical.NewEncoder(&buf).Encode(cal)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedObject{
+		ETag:   etag,
+		Data:   buf.String(),
+		parsed: cal,
+	}, nil
+}
+
+func writeFileAtomic(path string, contents []byte) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	_, err = tmp.Write(contents)
+	if err != nil {
+		tmp.Close()
+		return
+	}
+	err = tmp.Close()
+	if err != nil {
+		return
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,11 +1,19 @@
 package calendar
 
 import (
+	"bytes"
 	"calstats/internal/tel"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/emersion/go-ical"
@@ -16,8 +24,12 @@ import (
 )
 
 type eventId struct {
-	Uid string
-	RId string
+	// Path is the path of the calendar object that contains the event.
+	Path string
+	Uid  string
+	// RId is the recurrence id of the event, it is zero for single events
+	// and the original event of a recurring event.
+	RId time.Time
 
 	// ShouldOverride determines whether an override should be created for this
 	// event. It will be true if the given event is a recurrence instance and
@@ -26,14 +38,22 @@ type eventId struct {
 }
 
 type Caldav struct {
-	client *caldav.Client
-	ids    map[uint64]eventId
+	client   *caldav.Client
+	http     webdav.HTTPClient
+	endpoint *url.URL
+	// cache is nil if caching is disabled.
+	cache *caldavCache
+
+	ids *idRegistry
 }
 
 type CaldavOptions struct {
 	Username string
 	Password string
 	Insecure bool
+	// CacheDir is the directory calendar objects are cached in, the cache
+	// is disabled if it is empty.
+	CacheDir string
 }
 
 func NewCaldav(server string, opts CaldavOptions) (client Caldav, err error) {
@@ -63,12 +83,49 @@ func NewCaldav(server string, opts CaldavOptions) (client Caldav, err error) {
 	if err != nil {
 		return
 	}
+	endpoint, err := url.Parse(server)
+	if err != nil {
+		return
+	}
+	var cache *caldavCache
+	if opts.CacheDir != "" {
+		cache, err = newCaldavCache(cacheDirFor(opts.CacheDir, server, opts.Username))
+		if err != nil {
+			return
+		}
+	}
 	return Caldav{
-		client: inner,
+		client:   inner,
+		http:     webdavHttp,
+		endpoint: endpoint,
+		cache:    cache,
+		ids:      newIdRegistry(),
 	}, nil
 }
 
+// Calendars returns the calendars of the server. If the cache is enabled and
+// the server cannot be reached, the last known calendars are returned.
 func (c Caldav) Calendars(ctx context.Context) ([]Calendar, error) {
+	out, err := c.findCalendars(ctx)
+	if c.cache == nil {
+		return out, err
+	}
+	if err != nil {
+		cached, cacheErr := c.cache.loadCalendars()
+		if cacheErr != nil {
+			return nil, err
+		}
+		tel.Log.Warn("caldav", "list calendars failed, using cached calendars", "err", err)
+		return cached, nil
+	}
+	err = c.cache.saveCalendars(out)
+	if err != nil {
+		tel.Log.Warn("caldav", "cache calendars", "err", err)
+	}
+	return out, nil
+}
+
+func (c Caldav) findCalendars(ctx context.Context) ([]Calendar, error) {
 	homeSet, err := c.client.FindCalendarHomeSet(ctx, "")
 	if err != nil {
 		return nil, err
@@ -89,20 +146,99 @@ func (c Caldav) Calendars(ctx context.Context) ([]Calendar, error) {
 
 // adjustEventBounds crops the event so that it is within the interval bounds [intvStart, intvEnd].
 // If the event is outside the interval completely, it will return false in the second return value.
-func (c Caldav) adjustEventBounds(ev Event, intvStart, intvEnd time.Time) (Event, bool) {
+func adjustEventBounds(ev Event, intvStart, intvEnd time.Time) (Event, bool) {
 	if ev.End.Before(intvStart) || ev.Start.After(intvEnd) {
 		return ev, false
 	}
 	if ev.Start.Before(intvStart) {
 		ev.Start = intvStart
+		ev.Cropped = true
 	}
 	if ev.End.After(intvEnd) {
 		ev.End = intvEnd
+		ev.Cropped = true
 	}
 	return ev, true
 }
 
 func (c Caldav) Events(ctx context.Context, calendar Calendar, intvStart, intvEnd time.Time, tz *time.Location) ([]Event, error) {
+	var (
+		events   []caldavEvent
+		versions map[string]string
+		err      error
+	)
+	// the cache holds every object of the calendar, a query only the objects
+	// in the interval
+	complete := c.cache != nil
+	if complete {
+		events, versions, err = c.cachedEvents(ctx, calendar, intvStart, intvEnd, tz)
+	} else {
+		events, versions, err = c.queryEvents(ctx, calendar, intvStart, intvEnd, tz)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.ids.mutex.Unlock()
+	c.ids.mutex.Lock()
+
+	c.ids.observe(calendar.Id, versions, complete)
+	return expandEvents(events, intvStart, intvEnd, tz, c.ids.events), nil
+}
+
+// cachedEvents syncs the calendar into the cache and parses the events of the
+// cached objects that may be in the interval, it also returns the versions of
+// all objects. If the sync fails the events are read from the last synced
+// state, so that calendars remain available offline.
+func (c Caldav) cachedEvents(ctx context.Context, calendar Calendar, intvStart, intvEnd time.Time, tz *time.Location) ([]caldavEvent, map[string]string, error) {
+	cached, err := c.sync(ctx, calendar)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		var cacheErr error
+		cached, cacheErr = c.cached(calendar)
+		if cacheErr != nil || len(cached.Objects) == 0 {
+			return nil, nil, err
+		}
+		tel.Log.Warn("caldav", "sync failed, using cached events", "err", err)
+	}
+
+	lock := c.cache.calendarLock(calendar.Id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	paths := slices.Sorted(maps.Keys(cached.Objects))
+	var events []caldavEvent
+	versions := make(map[string]string, len(paths))
+	for _, objPath := range paths {
+		obj := cached.Objects[objPath]
+		versions[objPath] = obj.version()
+		if start, end, ok := obj.span(); ok && (end.Before(intvStart) || start.After(intvEnd)) {
+			continue
+		}
+		data, err := obj.calendar()
+		if err != nil {
+			tel.Log.Warn("caldav", "skip corrupted cached object", "path", objPath, "err", err)
+			continue
+		}
+		tzr := newTzResolver(data)
+		for _, e := range data.Events() {
+			parsed, err := parseEvent(e, tzr, intvEnd, tz)
+			if err != nil {
+				tel.Log.Warn("caldav", "skip corrupted event", "err", err)
+				continue
+			}
+			parsed.Path = objPath
+			events = append(events, parsed)
+		}
+	}
+	return events, versions, nil
+}
+
+// queryEvents queries the events in the interval from the server, it also
+// returns the etags of the objects they are in.
+func (c Caldav) queryEvents(ctx context.Context, calendar Calendar, intvStart, intvEnd time.Time, tz *time.Location) ([]caldavEvent, map[string]string, error) {
 	res, err := c.client.QueryCalendar(ctx, calendar.Id, &caldav.CalendarQuery{
 		CompFilter: caldav.CompFilter{
 			Name: ical.CompCalendar,
@@ -123,34 +259,61 @@ func (c Caldav) Events(ctx context.Context, calendar Calendar, intvStart, intvEn
 					ical.PropLocation,
 					ical.PropDateTimeStart,
 					ical.PropDateTimeEnd,
+					ical.PropDuration,
 					ical.PropCategories,
+					ical.PropExceptionDates,
 					ical.PropRecurrenceDates,
 					ical.PropRecurrenceID,
 					ical.PropRecurrenceRule,
 					ical.PropTrigger,
 				},
+				Comps: []caldav.CalendarCompRequest{{
+					Name:  ical.CompAlarm,
+					Props: []string{ical.PropTrigger},
+				}},
+			}, {
+				// used to resolve custom TZIDs
+				Name:     ical.CompTimezone,
+				AllProps: true,
+				AllComps: true,
 			}},
 		},
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var events []caldavEvent
+	versions := make(map[string]string, len(res))
 	for _, eobj := range res {
+		versions[eobj.Path] = eobj.ETag
+		tzr := newTzResolver(eobj.Data)
 		for _, e := range eobj.Data.Events() {
-			parsed, err := parseEvent(e, intvEnd, tz)
+			parsed, err := parseEvent(e, tzr, intvEnd, tz)
 			if err != nil {
 				tel.Log.Warn("caldav", "skip corrupted event", "err", err)
 				continue
 			}
+			parsed.Path = eobj.Path
 			events = append(events, parsed)
 		}
 	}
+	return events, versions, nil
+}
 
+// expandEvents expands recurring events into their instances, replacing
+// instances with their overrides, and crops all events to the interval. The
+// id of every returned event is registered in ids, unless ids is nil.
+func expandEvents(events []caldavEvent, intvStart, intvEnd time.Time, tz *time.Location, ids map[uint64]eventId) []Event {
 	intvStart = intvStart.In(tz)
 	intvEnd = intvEnd.In(tz)
 
+	register := func(id uint64, eid eventId) {
+		if ids != nil {
+			ids[id] = eid
+		}
+	}
+
 	var out []Event
 
 	type recurringEvent struct {
@@ -160,19 +323,14 @@ func (c Caldav) Events(ctx context.Context, calendar Calendar, intvStart, intvEn
 	recurring := map[string]recurringEvent{}
 	for _, e := range events {
 		track := recurring[e.Uid]
-		if e.RRule != nil { // original recurring event
+		if e.RRule != nil || (len(e.RDates) > 0 && e.RId.IsZero()) { // original recurring event
 			track.original = e
 		} else if e.RId != (time.Time{}) { // override instance of recurring event
 			track.overrides = append(track.overrides, e)
 		} else { // single event
-			outev, ok := c.adjustEventBounds(Event{
-				Id:      intId(e.Uid, formatICalDatetime(e.RId)),
-				Name:    e.Name,
-				Tags:    e.Categories,
-				Start:   e.Start,
-				End:     e.End,
-				Trigger: EventTrigger(e.Trigger),
-			}, intvStart, intvEnd)
+			id := instanceId(e.Uid, e.RId)
+			register(id, eventId{Path: e.Path, Uid: e.Uid})
+			outev, ok := adjustEventBounds(e.event(id, e.Start, e.End), intvStart, intvEnd)
 			if ok {
 				out = append(out, outev)
 			}
@@ -188,82 +346,191 @@ func (c Caldav) Events(ctx context.Context, calendar Calendar, intvStart, intvEn
 		}
 
 	recur:
-		for _, recurTime := range re.original.RRule.All() {
-			if recurTime.After(intvEnd) {
-				break
-			}
-			for _, e := range re.original.ExDates {
-				if e.Equal(recurTime) {
-					continue recur
-				}
-			}
-
+		for _, occ := range re.original.occurrences(intvEnd) {
+			recurTime := occ.Start
 			for _, ov := range re.overrides {
 				if !recurTime.Equal(ov.RId) {
 					continue
 				}
-				outev, ok := c.adjustEventBounds(Event{
-					Id:      intId(ov.Uid, formatICalDatetime(ov.RId)),
-					Name:    ov.Name,
-					Tags:    ov.Categories,
-					Start:   ov.Start,
-					End:     ov.End,
-					Trigger: EventTrigger(ov.Trigger),
-				}, intvStart, intvEnd)
+				id := instanceId(ov.Uid, ov.RId)
+				register(id, eventId{Path: ov.Path, Uid: ov.Uid, RId: ov.RId})
+				outev, ok := adjustEventBounds(ov.event(id, ov.Start, ov.End), intvStart, intvEnd)
 				if ok {
 					out = append(out, outev)
 				}
 				continue recur
 			}
 
-			outev, ok := c.adjustEventBounds(Event{
-				Id:      intId(re.original.Uid, formatICalDatetime(re.original.RId)),
-				Name:    re.original.Name,
-				Tags:    re.original.Categories,
-				Start:   recurTime,
-				End:     recurTime.Add(re.original.Duration),
-				Trigger: EventTrigger(re.original.Trigger),
-			}, intvStart, intvEnd)
+			id := instanceId(re.original.Uid, recurTime)
+			register(id, eventId{
+				Path:           re.original.Path,
+				Uid:            re.original.Uid,
+				RId:            recurTime,
+				ShouldOverride: true,
+			})
+			outev, ok := adjustEventBounds(
+				re.original.event(id, recurTime, recurTime.Add(occ.Duration)),
+				intvStart, intvEnd,
+			)
 			if ok {
 				out = append(out, outev)
 			}
 		}
 	}
 
-	return out, nil
+	return out
 }
 
+// Update writes the given event updates back to the server. Updates are
+// grouped by calendar object, each object is fetched, modified and then
+// written back with its ETag so that concurrent modifications are not
+// overwritten.
 func (c Caldav) Update(ctx context.Context, events []UpdateEvent) error {
-	// TODO: finish update
+	c.ids.mutex.Lock()
+	paths, byPath, err := groupUpdatesByPath(c.ids.events, events)
+	c.ids.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, objPath := range paths {
+		err := c.updateObject(ctx, objPath, byPath[objPath])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("update '%s': %w", objPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c Caldav) updateObject(ctx context.Context, objPath string, updates []UpdateEvent) error {
+	obj, err := c.client.GetCalendarObject(ctx, objPath)
+	if err != nil {
+		return err
+	}
+
+	c.ids.mutex.Lock()
+	ids := make([]eventId, len(updates))
+	for i, update := range updates {
+		ids[i] = c.ids.events[update.Id]
+	}
+	c.ids.mutex.Unlock()
+
+	err = applyUpdates(obj.Data, ids, updates)
+	if err != nil {
+		return err
+	}
+
+	err = c.putObject(ctx, objPath, obj.ETag, obj.Data)
+	if err != nil {
+		return err
+	}
+
+	c.ids.mutex.Lock()
+	markOverridden(c.ids.events, updates)
+	c.ids.mutex.Unlock()
+	return nil
+}
+
+// putObject writes a calendar object to the server, it only succeeds if the
+// object on the server still has the given etag.
+func (c Caldav) putObject(ctx context.Context, objPath, etag string, cal *ical.Calendar) error {
+	var buf bytes.Buffer
+	err := ical.NewEncoder(&buf).Encode(cal)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.resolveHref(objPath).String(), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ical.MIMEType)
+	if etag != "" {
+		req.Header.Set("If-Match", fmt.Sprintf("%q", etag))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("calendar object was modified on the server, please refetch events")
+	}
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("put calendar object: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
 	return nil
 }
 
+// resolveHref resolves a path returned by the server relative to the
+// caldav endpoint.
+func (c Caldav) resolveHref(p string) *url.URL {
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(c.endpoint.Path, p)
+	}
+	return &url.URL{
+		Scheme: c.endpoint.Scheme,
+		User:   c.endpoint.User,
+		Host:   c.endpoint.Host,
+		Path:   p,
+	}
+}
+
 type caldavEvent struct {
+	Path        string
 	Uid         string
 	Name        string
 	Location    string
 	Description string
 	Categories  []string
-	ExDates     []time.Time
+	ExDates     []exceptionDate
 	Start, End  time.Time
+	AllDay      bool
 	Duration    time.Duration
 	RRule       *rrule.RRule
-	RDates      string
+	RDates      []recurrenceDate
 	RId         time.Time
 	Trigger     EventTrigger
 }
 
+// event converts the parsed event into an Event with the given id and bounds.
+func (ce caldavEvent) event(id uint64, start, end time.Time) Event {
+	return Event{
+		Id:          id,
+		Name:        ce.Name,
+		Location:    ce.Location,
+		Description: ce.Description,
+		Tags:        ce.Categories,
+		Start:       start,
+		End:         end,
+		AllDay:      ce.AllDay,
+		Trigger:     EventTrigger(ce.Trigger),
+	}
+}
+
 // intId hashes a calendar event's UID and Recurrence ID into a single uint64.
 func intId(uid string, rid string) uint64 {
 	return xxh3.Hash([]byte(uid + rid))
 }
 
+// instanceId returns the id of the event instance with the given UID and
+// recurrence id. The recurrence id is normalized to UTC so that an instance
+// and its override get the same id regardless of the timezone they were
+// parsed in.
+func instanceId(uid string, rid time.Time) uint64 {
+	return intId(uid, formatICalDatetime(rid.In(time.UTC)))
+}
+
 // formatICalDatetime formats a given [time.Time] in the ical datetime format.
 func formatICalDatetime(t time.Time) string {
 	return t.Format("20060102T150405")
 }
 
-func parseEvent(e ical.Event, intvEnd time.Time, tz *time.Location) (event caldavEvent, err error) {
+func parseEvent(e ical.Event, tzr *tzResolver, intvEnd time.Time, tz *time.Location) (event caldavEvent, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("parse event: %w", err)
@@ -275,11 +542,11 @@ func parseEvent(e ical.Event, intvEnd time.Time, tz *time.Location) (event calda
 	(&event).ParseLocation(e)
 	(&event).ParseDescription(e)
 
-	err = (&event).ParseStart(e, tz)
+	err = (&event).ParseStart(e, tzr, tz)
 	if err != nil {
 		return
 	}
-	err = (&event).ParseEnd(e, tz)
+	err = (&event).ParseEnd(e, tzr, tz)
 	if err != nil {
 		return
 	}
@@ -289,15 +556,15 @@ func parseEvent(e ical.Event, intvEnd time.Time, tz *time.Location) (event calda
 	if err != nil {
 		return
 	}
-	err = (&event).ParseExceptions(e)
+	err = (&event).ParseExceptions(e, tzr, tz)
 	if err != nil {
 		return
 	}
-	err = (&event).ParseRecurrence(e, tz, event.Start, intvEnd)
+	err = (&event).ParseRecurrence(e, tzr, tz, event.Start, intvEnd)
 	if err != nil {
 		return
 	}
-	err = (&event).ParseTrigger(e, tz)
+	err = (&event).ParseTrigger(e, tzr, tz)
 	if err != nil {
 		return
 	}
@@ -359,17 +626,22 @@ func (ce *caldavEvent) ParseCategories(e ical.Event) error {
 	return nil
 }
 
-func (ce *caldavEvent) ParseStart(e ical.Event, tz *time.Location) error {
-	start, err := e.DateTimeStart(tz)
+func (ce *caldavEvent) ParseStart(e ical.Event, tzr *tzResolver, tz *time.Location) error {
+	start, err := tzr.eventStart(e, tz)
 	if err != nil {
 		return err
 	}
 	ce.Start = start
+	if startProp := e.Props.Get(ical.PropDateTimeStart); startProp != nil {
+		// some clients omit VALUE=DATE on all-day events
+		ce.AllDay = startProp.ValueType() == ical.ValueDate ||
+			len(strings.TrimSpace(startProp.Value)) == len("20060102")
+	}
 	return nil
 }
 
-func (ce *caldavEvent) ParseEnd(e ical.Event, tz *time.Location) error {
-	end, err := e.DateTimeEnd(tz)
+func (ce *caldavEvent) ParseEnd(e ical.Event, tzr *tzResolver, tz *time.Location) error {
+	end, err := tzr.eventEnd(e, tz)
 	if err != nil {
 		return err
 	}
@@ -377,44 +649,63 @@ func (ce *caldavEvent) ParseEnd(e ical.Event, tz *time.Location) error {
 	return nil
 }
 
-func (ce *caldavEvent) ParseExceptions(e ical.Event) error {
-	exProp := e.Props.Get(ical.PropExceptionDates)
-	if exProp == nil {
-		return nil
-	}
-
-	tzId := exProp.Params.Get(ical.PropTimezoneID)
-	var err error
-	var tz *time.Location
-	if tzId != "" {
-		tz, err = time.LoadLocation(tzId)
-		if err != nil {
-			return err
+func (ce *caldavEvent) ParseExceptions(e ical.Event, tzr *tzResolver, tz *time.Location) error {
+	for _, exProp := range e.Props[ical.PropExceptionDates] {
+		valueType := exProp.ValueType()
+		tzId := exProp.Params.Get(ical.PropTimezoneID)
+		for _, value := range strings.Split(exProp.Value, ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			valueType := valueType
+			// some clients omit VALUE=DATE on all-day exclusions
+			if len(value) == len("20060102") {
+				valueType = ical.ValueDate
+			}
+			date := valueType == ical.ValueDate
+			datetime, err := tzr.parseDateValue(value, valueType, tzId, tz)
+			if err != nil {
+				return fmt.Errorf("parse exdate: %w", err)
+			}
+			ce.ExDates = append(ce.ExDates, exceptionDate{Time: datetime, Date: date})
 		}
 	}
+	return nil
+}
 
-	var datetime time.Time
-	datetime, err = exProp.DateTime(tz)
-	if err != nil {
-		return err
-	}
+// exceptionDate is an instance of a recurring event excluded by EXDATE.
+type exceptionDate struct {
+	Time time.Time
+	// Date is true for DATE values, which exclude every instance starting on
+	// that day.
+	Date bool
+}
 
-	ce.ExDates = append(ce.ExDates, datetime)
-	return nil
+func (ex exceptionDate) excludes(t time.Time) bool {
+	if !ex.Date {
+		return ex.Time.Equal(t)
+	}
+	t = t.In(ex.Time.Location())
+	return t.Year() == ex.Time.Year() && t.YearDay() == ex.Time.YearDay()
 }
 
-func (ce *caldavEvent) ParseRecurrence(e ical.Event, tz *time.Location, start, intvEnd time.Time) (err error) {
+func (ce *caldavEvent) ParseRecurrence(e ical.Event, tzr *tzResolver, tz *time.Location, start, intvEnd time.Time) (err error) {
 	recurIdProp := e.Props.Get(ical.PropRecurrenceID)
 	if recurIdProp != nil && recurIdProp.Value != "" {
-		ce.RId, err = recurIdProp.DateTime(tz)
+		ce.RId, err = tzr.dateTime(recurIdProp, tz)
 		if err != nil {
 			return
 		}
 	}
 
-	rdateProp := e.Props.Get(ical.PropRecurrenceDates)
-	if rdateProp != nil {
-		ce.RDates = rdateProp.Value
+	for _, rdateProp := range e.Props[ical.PropRecurrenceDates] {
+		var rdates []recurrenceDate
+		rdates, err = parseRecurrenceDates(rdateProp, tzr, tz)
+		if err != nil {
+			return
+		}
+		ce.RDates = append(ce.RDates, rdates...)
 	}
 
 	rruleProp := e.Props.Get(ical.PropRecurrenceRule)
@@ -446,16 +737,129 @@ func (ce *caldavEvent) ParseRecurrence(e ical.Event, tz *time.Location, start, i
 	return
 }
 
-func (ce *caldavEvent) ParseTrigger(e ical.Event, tz *time.Location) (err error) {
+// recurrenceDate is an additional instance of a recurring event given by
+// RDATE.
+type recurrenceDate struct {
+	Start time.Time
+	// Duration is only set for PERIOD values, other values use the duration of
+	// the event.
+	Duration time.Duration
+}
+
+// parseRecurrenceDates parses the comma separated DATE, DATE-TIME or PERIOD
+// values of an RDATE property.
+func parseRecurrenceDates(prop ical.Prop, tzr *tzResolver, tz *time.Location) ([]recurrenceDate, error) {
+	valueType := prop.ValueType()
+	tzId := prop.Params.Get(ical.PropTimezoneID)
+
+	var out []recurrenceDate
+	for _, value := range strings.Split(prop.Value, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if valueType != ical.ValuePeriod {
+			start, err := tzr.parseDateValue(value, valueType, tzId, tz)
+			if err != nil {
+				return nil, fmt.Errorf("parse rdate: %w", err)
+			}
+			out = append(out, recurrenceDate{Start: start})
+			continue
+		}
+
+		startValue, endValue, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("parse rdate: invalid period '%s'", value)
+		}
+		start, err := tzr.parseDateValue(startValue, ical.ValueDateTime, tzId, tz)
+		if err != nil {
+			return nil, fmt.Errorf("parse rdate: %w", err)
+		}
+		var duration time.Duration
+		if strings.HasPrefix(endValue, "P") || strings.HasPrefix(endValue, "+P") || strings.HasPrefix(endValue, "-P") {
+			durationProp := ical.NewProp(ical.PropDuration)
+			durationProp.Value = endValue
+			duration, err = durationProp.Duration()
+		} else {
+			var end time.Time
+			end, err = tzr.parseDateValue(endValue, ical.ValueDateTime, tzId, tz)
+			duration = end.Sub(start)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse rdate: %w", err)
+		}
+		out = append(out, recurrenceDate{Start: start, Duration: duration})
+	}
+	return out, nil
+}
+
+type occurrence struct {
+	Start    time.Time
+	Duration time.Duration
+}
+
+// occurrences returns the recurrence set of a recurring event until intvEnd,
+// that is its start, the instances of its RRULE and its RDATEs without the
+// EXDATEs, ordered by start.
+func (ce caldavEvent) occurrences(intvEnd time.Time) []occurrence {
+	var out []occurrence
+	if !ce.Start.After(intvEnd) {
+		out = append(out, occurrence{Start: ce.Start, Duration: ce.Duration})
+	}
+	if ce.RRule != nil {
+		for _, t := range ce.RRule.All() {
+			if t.After(intvEnd) {
+				break
+			}
+			out = append(out, occurrence{Start: t, Duration: ce.Duration})
+		}
+	}
+	for _, rdate := range ce.RDates {
+		if rdate.Start.After(intvEnd) {
+			continue
+		}
+		occ := occurrence{Start: rdate.Start, Duration: ce.Duration}
+		if rdate.Duration != 0 {
+			occ.Duration = rdate.Duration
+		}
+		out = append(out, occ)
+	}
+
+	// stable so that the instances of the rule come before rdates with the same
+	// start, which are then dropped as duplicates
+	slices.SortStableFunc(out, func(a, b occurrence) int {
+		return a.Start.Compare(b.Start)
+	})
+	out = slices.CompactFunc(out, func(a, b occurrence) bool {
+		return a.Start.Equal(b.Start)
+	})
+	return slices.DeleteFunc(out, func(occ occurrence) bool {
+		return slices.ContainsFunc(ce.ExDates, func(ex exceptionDate) bool {
+			return ex.excludes(occ.Start)
+		})
+	})
+}
+
+func (ce *caldavEvent) ParseTrigger(e ical.Event, tzr *tzResolver, tz *time.Location) (err error) {
 	triggerProp := e.Props.Get(ical.PropTrigger)
+	if triggerProp == nil {
+		for _, child := range e.Children {
+			if child.Name == ical.CompAlarm {
+				triggerProp = child.Props.Get(ical.PropTrigger)
+				break
+			}
+		}
+	}
 	if triggerProp == nil {
 		return
 	}
 	ce.Trigger.Relative, err = triggerProp.Duration()
 	if err == nil {
+		ce.Trigger.NotNone = true
 		return
 	}
-	ce.Trigger.Absolute, err = triggerProp.DateTime(tz)
+	ce.Trigger.Absolute, err = tzr.dateTime(triggerProp, tz)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,179 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// expandObject parses the events of an iCalendar object and expands them in
+// the interval.
+func expandObject(t *testing.T, object string, intvStart, intvEnd time.Time, tz *time.Location) []Event {
+	t.Helper()
+	cal, err := ical.NewDecoder(strings.NewReader(strings.ReplaceAll(object, "\n", "\r\n"))).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []caldavEvent
+	tzr := newTzResolver(cal)
+	for _, e := range cal.Events() {
+		parsed, err := parseEvent(e, tzr, intvEnd, tz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, parsed)
+	}
+	return expandEvents(events, intvStart, intvEnd, tz, map[uint64]eventId{})
+}
+
+// formatEvents formats events as "MMDD HH:MM-HH:MM Name" in tz.
+func formatEvents(events []Event, tz *time.Location) map[string]int {
+	out := map[string]int{}
+	for _, e := range events {
+		out[e.Start.In(tz).Format("0102 15:04")+"-"+e.End.In(tz).Format("15:04")+" "+e.Name]++
+	}
+	return out
+}
+
+func expectEvents(t *testing.T, got map[string]int, expect []string) {
+	t.Helper()
+	total := 0
+	for _, n := range got {
+		total += n
+	}
+	if total != len(expect) {
+		t.Fatalf("expected %d events, got %v", len(expect), got)
+	}
+	for _, e := range expect {
+		if got[e] != 1 {
+			t.Fatalf("expected event '%s' once, got %v", e, got)
+		}
+	}
+}
+
+func TestRecurrenceDates(t *testing.T) {
+	const object = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//calstats//test//EN
+BEGIN:VEVENT
+UID:review
+DTSTAMP:20240101T000000Z
+SUMMARY:Review
+LOCATION:Room 4.12
+DESCRIPTION:project PRJ-42
+DTSTART;TZID=Europe/Berlin:20240101T100000
+DTEND;TZID=Europe/Berlin:20240101T110000
+RRULE:FREQ=WEEKLY;COUNT=2
+RDATE;TZID=Europe/Berlin:20240103T150000,20240108T100000
+RDATE;VALUE=PERIOD:20240104T120000Z/PT30M,20240105T120000Z/20240105T140000Z
+RDATE:20240106T090000Z
+EXDATE:20240106T090000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:retreat
+DTSTAMP:20240101T000000Z
+SUMMARY:Retreat
+DTSTART;VALUE=DATE:20240102
+DTEND;VALUE=DATE:20240103
+RDATE;VALUE=DATE:20240109
+END:VEVENT
+BEGIN:VEVENT
+UID:holiday
+DTSTAMP:20240101T000000Z
+SUMMARY:Holiday
+DTSTART:20240110
+END:VEVENT
+END:VCALENDAR
+`
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, berlin)
+	end := time.Date(2024, time.January, 15, 0, 0, 0, 0, berlin)
+	events := expandObject(t, object, start, end, berlin)
+
+	expectEvents(t, formatEvents(events, berlin), []string{
+		"0101 10:00-11:00 Review",
+		"0103 15:00-16:00 Review",
+		"0104 13:00-13:30 Review",
+		"0105 13:00-15:00 Review",
+		// the second rule instance and the rdate are the same instance
+		"0108 10:00-11:00 Review",
+		"0102 00:00-00:00 Retreat",
+		"0109 00:00-00:00 Retreat",
+		// a date without VALUE=DATE
+		"0110 00:00-00:00 Holiday",
+	})
+	for _, e := range events {
+		if e.AllDay != (e.Name == "Retreat" || e.Name == "Holiday") {
+			t.Fatalf("expected only 'Retreat' and 'Holiday' to be all-day, got %+v", e)
+		}
+		if e.Name == "Review" && (e.Location != "Room 4.12" || e.Description != "project PRJ-42") {
+			t.Fatalf("expected instances to keep location and description, got %+v", e)
+		}
+	}
+}
+
+func TestExceptionDates(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, berlin)
+	end := time.Date(2024, time.January, 15, 0, 0, 0, 0, berlin)
+
+	tests := []struct {
+		fixture string
+		expect  []string
+	}{
+		{
+			// repeated EXDATE properties with TZID
+			fixture: "nextcloud.ics",
+			expect: []string{
+				"0101 09:00-09:15 Standup",
+				"0105 09:00-09:15 Standup",
+				"0110 09:00-09:15 Standup",
+				"0112 09:00-09:15 Standup",
+			},
+		},
+		{
+			// a single EXDATE with multiple UTC values
+			fixture: "radicale.ics",
+			expect: []string{
+				"0101 19:00-20:00 Gym",
+				"0103 19:00-20:00 Gym",
+				"0105 19:00-20:00 Gym",
+				"0107 19:00-20:00 Gym",
+				"0108 19:00-20:00 Gym",
+				"0109 19:00-20:00 Gym",
+				"0110 19:00-20:00 Gym",
+			},
+		},
+		{
+			// multi-valued and repeated VALUE=DATE exclusions
+			fixture: "baikal.ics",
+			expect: []string{
+				"0108 00:00-00:00 Vacation",
+				"0109 00:00-00:00 Vacation",
+				"0112 00:00-00:00 Vacation",
+				"0114 00:00-00:00 Vacation",
+				"0102 23:00-00:00 Piano lesson",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			object, err := os.ReadFile(filepath.Join("testdata", "exdate", test.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := expandObject(t, string(object), start, end, berlin)
+			expectEvents(t, formatEvents(events, berlin), test.expect)
+		})
+	}
+}
@@ -18,7 +18,13 @@ type Event struct {
 	Description string
 	Tags        []string
 	Start, End  time.Time
-	Trigger     EventTrigger
+	// AllDay is true if the event starts on a date instead of a time, all-day
+	// events span one or more whole days.
+	AllDay  bool
+	Trigger EventTrigger
+	// Cropped is true if the event was cut to the requested interval, its
+	// Start or End is then a bound of the interval instead of its own.
+	Cropped bool
 }
 
 func (e Event) Duration() time.Duration {
@@ -0,0 +1,184 @@
+package calendar
+
+import (
+	"calstats/internal/tel"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav"
+)
+
+// Feed reads a read-only iCalendar feed published at a url (a subscribed
+// webcal:// or https:// .ics calendar). The feed is only downloaded again if
+// the server reports that it has changed.
+type Feed struct {
+	url  string
+	http webdav.HTTPClient
+
+	mutex *sync.Mutex
+	cache *feedCache
+}
+
+type feedCache struct {
+	etag         string
+	lastModified string
+	data         *ical.Calendar
+}
+
+type FeedOptions struct {
+	Username string
+	Password string
+	Insecure bool
+}
+
+func NewFeed(feedUrl string, opts FeedOptions) (feed Feed, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("new feed: %w", err)
+		}
+	}()
+
+	u, err := url.Parse(feedUrl)
+	if err != nil {
+		return
+	}
+	switch u.Scheme {
+	case "webcal":
+		u.Scheme = "http"
+	case "webcals":
+		u.Scheme = "https"
+	case "http", "https":
+	default:
+		err = fmt.Errorf("unsupported scheme '%s'", u.Scheme)
+		return
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: opts.Insecure,
+		},
+	}
+	httpClient := &http.Client{
+		Transport: transport,
+		Timeout:   10 * time.Second,
+	}
+
+	feedHttp := webdav.HTTPClient(httpClient)
+	if opts.Username != "" && opts.Password != "" {
+		feedHttp = webdav.HTTPClientWithBasicAuth(httpClient, opts.Username, opts.Password)
+	}
+
+	return Feed{
+		url:   u.String(),
+		http:  feedHttp,
+		mutex: &sync.Mutex{},
+		cache: &feedCache{},
+	}, nil
+}
+
+// fetch returns the contents of the feed, it only downloads the feed if it
+// changed since the last fetch.
+func (f Feed) fetch(ctx context.Context) (*ical.Calendar, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ical.MIMEType)
+
+	f.mutex.Lock()
+	cache := *f.cache
+	f.mutex.Unlock()
+
+	if cache.data != nil {
+		if cache.etag != "" {
+			req.Header.Set("If-None-Match", cache.etag)
+		}
+		if cache.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cache.lastModified)
+		}
+	}
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cache.data != nil {
+		tel.Log.Debug("feed", "not modified", "url", f.url)
+		return cache.data, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("fetch feed: %s", resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil && mediaType != ical.MIMEType && !strings.HasPrefix(mediaType, "text/plain") && mediaType != "application/octet-stream" {
+			return nil, fmt.Errorf("fetch feed: expected Content-Type %q, got %q", ical.MIMEType, mediaType)
+		}
+	}
+
+	data, err := ical.NewDecoder(resp.Body).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("decode feed: %w", err)
+	}
+
+	f.mutex.Lock()
+	*f.cache = feedCache{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		data:         data,
+	}
+	f.mutex.Unlock()
+	return data, nil
+}
+
+// Calendars returns the single calendar published by the feed.
+func (f Feed) Calendars(ctx context.Context) ([]Calendar, error) {
+	data, err := f.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err := data.Props.Text("X-WR-CALNAME")
+	if err != nil || name == "" {
+		u, _ := url.Parse(f.url)
+		name = strings.TrimSuffix(path.Base(u.Path), "."+ical.Extension)
+	}
+	return []Calendar{{Id: f.url, Name: name}}, nil
+}
+
+func (f Feed) Events(ctx context.Context, calendar Calendar, intvStart, intvEnd time.Time, tz *time.Location) ([]Event, error) {
+	data, err := f.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []caldavEvent
+	tzr := newTzResolver(data)
+	for _, e := range data.Events() {
+		parsed, err := parseEvent(e, tzr, intvEnd, tz)
+		if err != nil {
+			tel.Log.Warn("feed", "skip corrupted event", "err", err)
+			continue
+		}
+		parsed.Path = f.url
+		events = append(events, parsed)
+	}
+
+	// feeds are read only, so the ids don't have to be resolved later
+	return expandEvents(events, intvStart, intvEnd, tz, nil), nil
+}
+
+// Update always fails, as feeds are read only.
+func (f Feed) Update(ctx context.Context, events []UpdateEvent) error {
+	return fmt.Errorf("update event: feed '%s' is read only", f.url)
+}
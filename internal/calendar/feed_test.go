@@ -0,0 +1,76 @@
+package calendar
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+const feedContents = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//calstats//test//EN
+X-WR-CALNAME:Holidays
+BEGIN:VEVENT
+UID:new-year
+DTSTAMP:20240101T000000Z
+SUMMARY:New Year
+DTSTART:20240101T000000Z
+DTEND:20240101T120000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestFeed(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("Content-Type", ical.MIMEType)
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, strings.ReplaceAll(feedContents, "\n", "\r\n"))
+	}))
+	defer server.Close()
+
+	feed, err := NewFeed(strings.Replace(server.URL, "http://", "webcal://", 1)+"/holidays.ics", FeedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cals, err := feed.Calendars(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cals) != 1 || cals[0].Name != "Holidays" {
+		t.Fatalf("expected a single calendar named 'Holidays', got %+v", cals)
+	}
+
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	for range 2 {
+		events, err := feed.Events(ctx, cals[0], start, end, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].Name != "New Year" {
+			t.Fatalf("expected a single event 'New Year', got %+v", events)
+		}
+	}
+	if downloads != 1 {
+		t.Fatalf("expected the feed to be downloaded once, got %d downloads", downloads)
+	}
+
+	err = feed.Update(ctx, []UpdateEvent{{Id: 1}})
+	if err == nil {
+		t.Fatal("expected updating a feed to fail")
+	}
+}
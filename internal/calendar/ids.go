@@ -0,0 +1,59 @@
+package calendar
+
+import "sync"
+
+// idRegistry resolves the ids handed out with events to the events they
+// refer to. Ids are derived from the UID and recurrence id of an instance, so
+// fetching an event again registers the same id. The ids of a calendar object
+// are kept until the object changes or is removed, so ids handed out earlier
+// stay valid whatever interval was fetched since.
+type idRegistry struct {
+	mutex  sync.Mutex
+	events map[uint64]eventId
+	// objects holds the last seen version of every object by path, grouped
+	// by the calendar the objects are in
+	objects map[string]map[string]string
+}
+
+func newIdRegistry() *idRegistry {
+	return &idRegistry{
+		events:  map[uint64]eventId{},
+		objects: map[string]map[string]string{},
+	}
+}
+
+// observe records the versions of the objects of the calendar, like their
+// etags. The ids of objects whose version changed are dropped. If complete
+// is true, versions holds every object of the calendar and the ids of the
+// objects missing from it are dropped as well. The mutex must be held.
+func (r *idRegistry) observe(calendarId string, versions map[string]string, complete bool) {
+	known, ok := r.objects[calendarId]
+	if !ok {
+		known = map[string]string{}
+		r.objects[calendarId] = known
+	}
+
+	stale := map[string]bool{}
+	for objPath, version := range versions {
+		if old, ok := known[objPath]; ok && old != version {
+			stale[objPath] = true
+		}
+		known[objPath] = version
+	}
+	if complete {
+		for objPath := range known {
+			if _, ok := versions[objPath]; !ok {
+				stale[objPath] = true
+				delete(known, objPath)
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	for id, eid := range r.events {
+		if stale[eid.Path] {
+			delete(r.events, id)
+		}
+	}
+}
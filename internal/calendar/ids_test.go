@@ -0,0 +1,42 @@
+package calendar
+
+import "testing"
+
+func TestIdRegistryObserve(t *testing.T) {
+	r := newIdRegistry()
+	r.observe("/cal/work/", map[string]string{"/cal/work/a.ics": "1", "/cal/work/b.ics": "1"}, true)
+	r.observe("/cal/private/", map[string]string{"/cal/private/c.ics": "1"}, true)
+	for id, objPath := range map[uint64]string{
+		1: "/cal/work/a.ics",
+		2: "/cal/work/a.ics",
+		3: "/cal/work/b.ics",
+		4: "/cal/private/c.ics",
+	} {
+		r.events[id] = eventId{Path: objPath}
+	}
+
+	tests := []struct {
+		name     string
+		calendar string
+		versions map[string]string
+		complete bool
+		expect   []uint64
+	}{
+		// fetching another interval sees fewer objects, their ids stay
+		{"partial", "/cal/work/", map[string]string{"/cal/work/a.ics": "1"}, false, []uint64{1, 2, 3, 4}},
+		{"changed", "/cal/work/", map[string]string{"/cal/work/a.ics": "2"}, false, []uint64{3, 4}},
+		{"removed", "/cal/work/", map[string]string{"/cal/work/a.ics": "2"}, true, []uint64{4}},
+	}
+	for _, test := range tests {
+		r.observe(test.calendar, test.versions, test.complete)
+		if len(r.events) != len(test.expect) {
+			t.Errorf("%s: expected ids %v, got %v", test.name, test.expect, r.events)
+			continue
+		}
+		for _, id := range test.expect {
+			if _, ok := r.events[id]; !ok {
+				t.Errorf("%s: expected id %d to be kept", test.name, id)
+			}
+		}
+	}
+}
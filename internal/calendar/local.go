@@ -0,0 +1,295 @@
+package calendar
+
+import (
+	"calstats/internal/tel"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// Local reads calendars from .ics files on disk. The path can either be a
+// single .ics file, a vdir collection (a directory with one .ics file per
+// event, as used by vdirsyncer and khal) or a directory of vdir collections.
+type Local struct {
+	path string
+
+	ids *idRegistry
+}
+
+func NewLocal(path string) (local Local, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("new local calendar: %w", err)
+		}
+	}()
+
+	_, err = os.Stat(path)
+	if err != nil {
+		return
+	}
+	return Local{
+		path: path,
+		ids:  newIdRegistry(),
+	}, nil
+}
+
+func isIcsFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), "."+ical.Extension)
+}
+
+// Calendars returns a calendar for the .ics file, or a calendar for each vdir
+// collection in the directory.
+func (l Local) Calendars(ctx context.Context) ([]Calendar, error) {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		name, err := icsCalendarName(l.path)
+		if err != nil {
+			return nil, err
+		}
+		return []Calendar{{Id: l.path, Name: name}}, nil
+	}
+
+	var out []Calendar
+	isCollection, err := isVdirCollection(l.path)
+	if err != nil {
+		return nil, err
+	}
+	if isCollection {
+		out = append(out, Calendar{Id: l.path, Name: vdirCalendarName(l.path)})
+	}
+
+	entries, err := os.ReadDir(l.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(l.path, entry.Name())
+		isCollection, err := isVdirCollection(dir)
+		if err != nil {
+			return nil, err
+		}
+		if isCollection {
+			out = append(out, Calendar{Id: dir, Name: vdirCalendarName(dir)})
+		}
+	}
+	return out, nil
+}
+
+// isVdirCollection returns true if the directory contains .ics files or vdir
+// metadata.
+func isVdirCollection(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if isIcsFile(entry.Name()) || entry.Name() == "displayname" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// vdirCalendarName returns the name of a vdir collection, which is stored in
+// the "displayname" file by vdirsyncer's metasync. It falls back to the name
+// of the directory.
+func vdirCalendarName(dir string) string {
+	displayName, err := os.ReadFile(filepath.Join(dir, "displayname"))
+	if err == nil && strings.TrimSpace(string(displayName)) != "" {
+		return strings.TrimSpace(string(displayName))
+	}
+	return filepath.Base(dir)
+}
+
+// icsCalendarName returns the name of a calendar stored in a single .ics
+// file, which is stored in the X-WR-CALNAME property. It falls back to the name
+// of the file without its extension.
+func icsCalendarName(path string) (string, error) {
+	cal, err := readIcs(path)
+	if err != nil {
+		return "", err
+	}
+	if name, err := cal.Props.Text("X-WR-CALNAME"); err == nil && name != "" {
+		return name, nil
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), nil
+}
+
+func readIcs(path string) (*ical.Calendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cal, err := ical.NewDecoder(f).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("decode '%s': %w", path, err)
+	}
+	return cal, nil
+}
+
+// writeIcs atomically replaces the file at path with the calendar.
+func writeIcs(path string, cal *ical.Calendar) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".calstats-*.ics")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	err = ical.NewEncoder(tmp).Encode(cal)
+	if err != nil {
+		tmp.Close()
+		return
+	}
+	err = tmp.Close()
+	if err != nil {
+		return
+	}
+	if info, statErr := os.Stat(path); statErr == nil {
+		err = os.Chmod(tmp.Name(), info.Mode())
+		if err != nil {
+			return
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// files returns the .ics files that belong to the calendar.
+func (l Local) files(calendar Calendar) ([]string, error) {
+	info, err := os.Stat(calendar.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{calendar.Id}, nil
+	}
+
+	entries, err := os.ReadDir(calendar.Id)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !isIcsFile(entry.Name()) {
+			continue
+		}
+		out = append(out, filepath.Join(calendar.Id, entry.Name()))
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func (l Local) Events(ctx context.Context, calendar Calendar, intvStart, intvEnd time.Time, tz *time.Location) ([]Event, error) {
+	files, err := l.files(calendar)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []caldavEvent
+	versions := make(map[string]string, len(files))
+	for _, path := range files {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			tel.Log.Warn("local", "skip unreadable file", "err", err)
+			continue
+		}
+		versions[path] = fileVersion(info)
+		cal, err := readIcs(path)
+		if err != nil {
+			tel.Log.Warn("local", "skip corrupted file", "err", err)
+			continue
+		}
+		tzr := newTzResolver(cal)
+		for _, e := range cal.Events() {
+			parsed, err := parseEvent(e, tzr, intvEnd, tz)
+			if err != nil {
+				tel.Log.Warn("local", "skip corrupted event", "path", path, "err", err)
+				continue
+			}
+			parsed.Path = path
+			events = append(events, parsed)
+		}
+	}
+
+	defer l.ids.mutex.Unlock()
+	l.ids.mutex.Lock()
+
+	l.ids.observe(calendar.Id, versions, true)
+	return expandEvents(events, intvStart, intvEnd, tz, l.ids.events), nil
+}
+
+// fileVersion identifies the contents of a file by its modification time and
+// size.
+func fileVersion(info os.FileInfo) string {
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// Update writes the given event updates to the .ics files that contain them.
+func (l Local) Update(ctx context.Context, events []UpdateEvent) error {
+	l.ids.mutex.Lock()
+	paths, byPath, err := groupUpdatesByPath(l.ids.events, events)
+	l.ids.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range paths {
+		err := l.updateFile(path, byPath[path])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("update '%s': %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l Local) updateFile(path string, updates []UpdateEvent) error {
+	cal, err := readIcs(path)
+	if err != nil {
+		return err
+	}
+
+	l.ids.mutex.Lock()
+	ids := make([]eventId, len(updates))
+	for i, update := range updates {
+		ids[i] = l.ids.events[update.Id]
+	}
+	l.ids.mutex.Unlock()
+
+	err = applyUpdates(cal, ids, updates)
+	if err != nil {
+		return err
+	}
+	err = writeIcs(path, cal)
+	if err != nil {
+		return err
+	}
+
+	l.ids.mutex.Lock()
+	markOverridden(l.ids.events, updates)
+	l.ids.mutex.Unlock()
+	return nil
+}
@@ -0,0 +1,143 @@
+package calendar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const vdirRecurring = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//calstats//test//EN
+BEGIN:VEVENT
+UID:gym
+DTSTAMP:20240101T000000Z
+SUMMARY:Gym
+CATEGORIES:health
+DTSTART:20240101T180000Z
+DTEND:20240101T190000Z
+RRULE:FREQ=DAILY;COUNT=4
+EXDATE:20240102T180000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:gym
+DTSTAMP:20240101T000000Z
+RECURRENCE-ID:20240103T180000Z
+SUMMARY:Gym (late)
+CATEGORIES:health
+DTSTART:20240103T200000Z
+DTEND:20240103T210000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+const vdirSingle = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//calstats//test//EN
+BEGIN:VEVENT
+UID:dentist
+DTSTAMP:20240101T000000Z
+SUMMARY:Dentist
+DTSTART:20240102T090000Z
+DTEND:20240102T100000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(strings.ReplaceAll(contents, "\n", "\r\n")), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "personal", "displayname"), "Personal\n")
+	writeFile(t, filepath.Join(dir, "personal", "gym.ics"), vdirRecurring)
+	writeFile(t, filepath.Join(dir, "personal", "dentist.ics"), vdirSingle)
+	writeFile(t, filepath.Join(dir, "empty", "README"), "not a calendar")
+
+	local, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cals, err := local.Calendars(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cals) != 1 || cals[0].Name != "Personal" {
+		t.Fatalf("expected a single calendar named 'Personal', got %+v", cals)
+	}
+
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC)
+	events, err := local.Events(ctx, cals[0], start, end, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]int{}
+	for _, e := range events {
+		got[e.Start.Format("0102 15:04")+" "+e.Name]++
+	}
+	expect := []string{
+		"0101 18:00 Gym",
+		"0102 09:00 Dentist",
+		"0103 20:00 Gym (late)",
+		"0104 18:00 Gym",
+	}
+	if len(events) != len(expect) {
+		t.Fatalf("expected %d events, got %v", len(expect), got)
+	}
+	for _, e := range expect {
+		if got[e] != 1 {
+			t.Fatalf("expected event '%s', got %v", e, got)
+		}
+	}
+
+	var dentist Event
+	for _, e := range events {
+		if e.Name == "Dentist" {
+			dentist = e
+		}
+	}
+	tags := []string{"health"}
+	err = local.Update(ctx, []UpdateEvent{{Id: dentist.Id, Tags: &tags}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err = local.Events(ctx, cals[0], start, end, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if e.Name == "Dentist" && (len(e.Tags) != 1 || e.Tags[0] != "health") {
+			t.Fatalf("expected updated tags to be read back, got %v", e.Tags)
+		}
+	}
+
+	// the ids of removed files are dropped
+	err = os.Remove(filepath.Join(dir, "personal", "dentist.ics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = local.Events(ctx, cals[0], start, end, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := local.ids.events[dentist.Id]; ok || len(local.ids.events) != 3 {
+		t.Fatalf("expected only the ids of the gym events, got %v", local.ids.events)
+	}
+}
@@ -0,0 +1,308 @@
+package calendar
+
+import (
+	"bytes"
+	"calstats/internal/tel"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-ical"
+	"github.com/emersion/go-webdav/caldav"
+)
+
+// multiGetBatchSize limits the amount of calendar objects requested in a
+// single calendar-multiget report.
+const multiGetBatchSize = 100
+
+var (
+	// errInvalidSyncToken is returned by syncCollection if the server no
+	// longer accepts the sync token, a full sync is required.
+	errInvalidSyncToken = errors.New("invalid sync token")
+	// errSyncUnsupported is returned by syncCollection if the server does not
+	// support the sync-collection report.
+	errSyncUnsupported = errors.New("sync-collection not supported")
+)
+
+// syncChanges describes the members of a collection that changed since the
+// last sync.
+type syncChanges struct {
+	// Changed maps the paths of new or modified objects to their etags.
+	Changed map[string]string
+	// Removed contains the paths of removed objects.
+	Removed []string
+	// Full is true if Changed contains every member of the collection, so
+	// that all other cached objects can be considered removed.
+	Full bool
+	// SyncToken is the new sync token, empty if sync-collection is not used.
+	SyncToken string
+}
+
+// sync brings the cached copy of the calendar up to date with the server and
+// returns it. It uses sync-collection (RFC 6578) when the server supports it
+// and falls back to comparing the etags of all objects otherwise. The lock of
+// the calendar is only held to load and save it, a concurrent sync of the
+// same calendar may be overwritten by an equally recent state.
+func (c Caldav) sync(ctx context.Context, calendar Calendar) (cached *cachedCalendar, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("sync '%s': %w", calendar.Id, err)
+		}
+	}()
+
+	lock := c.cache.calendarLock(calendar.Id)
+	lock.Lock()
+	cached, err = c.cache.load(calendar.Id)
+	lock.Unlock()
+	if err != nil {
+		return
+	}
+
+	noSyncCollection := cached.NoSyncCollection
+	var changes syncChanges
+	if !noSyncCollection {
+		changes, err = c.syncCollection(ctx, calendar.Id, cached.SyncToken)
+		if errors.Is(err, errInvalidSyncToken) && cached.SyncToken != "" {
+			tel.Log.Debug("caldav", "sync token expired, resyncing", "calendar", calendar.Id)
+			changes, err = c.syncCollection(ctx, calendar.Id, "")
+		}
+		if errors.Is(err, errSyncUnsupported) {
+			tel.Log.Debug("caldav", "sync-collection unsupported, comparing etags", "calendar", calendar.Id)
+			noSyncCollection = true
+		} else if err != nil {
+			return
+		}
+	}
+	if noSyncCollection {
+		changes, err = c.listEtags(ctx, calendar.Id)
+		if err != nil {
+			return
+		}
+	}
+
+	objects := make(map[string]*cachedObject, len(cached.Objects))
+	for objPath, obj := range cached.Objects {
+		objects[objPath] = obj
+	}
+	if changes.Full {
+		for objPath := range objects {
+			if _, ok := changes.Changed[objPath]; !ok {
+				delete(objects, objPath)
+			}
+		}
+	}
+	for _, objPath := range changes.Removed {
+		delete(objects, objPath)
+	}
+
+	var fetch []string
+	for objPath, etag := range changes.Changed {
+		if obj, ok := objects[objPath]; ok && etag != "" && obj.ETag == etag {
+			continue
+		}
+		fetch = append(fetch, objPath)
+	}
+	slices.Sort(fetch)
+
+	for batch := range slices.Chunk(fetch, multiGetBatchSize) {
+		var res []caldav.CalendarObject
+		res, err = c.client.MultiGetCalendar(ctx, calendar.Id, &caldav.CalendarMultiGet{
+			Paths: batch,
+			CompRequest: caldav.CalendarCompRequest{
+				Name:     ical.CompCalendar,
+				AllProps: true,
+				AllComps: true,
+			},
+		})
+		if err != nil {
+			return
+		}
+		for _, obj := range res {
+			var cachedObj *cachedObject
+			cachedObj, err = newCachedObject(obj.ETag, obj.Data)
+			if err != nil {
+				return
+			}
+			objects[obj.Path] = cachedObj
+		}
+	}
+
+	tel.Log.Debug("caldav", "synced calendar",
+		"calendar", calendar.Id,
+		"fetched", len(fetch),
+		"objects", len(objects),
+	)
+
+	updated := &cachedCalendar{
+		SyncToken:        changes.SyncToken,
+		NoSyncCollection: noSyncCollection,
+		Objects:          objects,
+	}
+	lock.Lock()
+	err = c.cache.save(calendar.Id, updated)
+	lock.Unlock()
+	if err != nil {
+		return
+	}
+	return updated, nil
+}
+
+// cached returns the cached copy of the calendar without syncing it.
+func (c Caldav) cached(calendar Calendar) (*cachedCalendar, error) {
+	lock := c.cache.calendarLock(calendar.Id)
+	lock.Lock()
+	defer lock.Unlock()
+	return c.cache.load(calendar.Id)
+}
+
+// listEtags returns the etags of all objects in the calendar.
+func (c Caldav) listEtags(ctx context.Context, calPath string) (syncChanges, error) {
+	files, err := c.client.ReadDir(ctx, calPath, false)
+	if err != nil {
+		return syncChanges{}, err
+	}
+	changes := syncChanges{
+		Changed: map[string]string{},
+		Full:    true,
+	}
+	for _, f := range files {
+		if f.IsDir {
+			continue
+		}
+		changes.Changed[f.Path] = f.ETag
+	}
+	return changes, nil
+}
+
+type syncCollectionQuery struct {
+	XMLName   xml.Name `xml:"DAV: sync-collection"`
+	SyncToken string   `xml:"sync-token"`
+	SyncLevel string   `xml:"sync-level"`
+	Prop      struct {
+		GetETag struct{} `xml:"DAV: getetag"`
+	} `xml:"DAV: prop"`
+}
+
+type syncMultiStatus struct {
+	XMLName   xml.Name `xml:"DAV: multistatus"`
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Status   string `xml:"DAV: status"`
+		PropStat []struct {
+			Status string `xml:"DAV: status"`
+			ETag   string `xml:"DAV: prop>getetag"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+	SyncToken string `xml:"DAV: sync-token"`
+}
+
+// syncCollection runs a sync-collection report on the calendar, an empty
+// sync token requests all members of the collection.
+func (c Caldav) syncCollection(ctx context.Context, calPath, syncToken string) (changes syncChanges, err error) {
+	query := syncCollectionQuery{
+		SyncToken: syncToken,
+		SyncLevel: "1",
+	}
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	err = xml.NewEncoder(&body).Encode(query)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "REPORT", c.resolveHref(calPath).String(), &body)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "0")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		switch {
+		case strings.Contains(string(errBody), "valid-sync-token"):
+			err = errInvalidSyncToken
+		case resp.StatusCode == http.StatusUnauthorized:
+			err = fmt.Errorf("sync-collection: %s", resp.Status)
+		case resp.StatusCode/100 == 4 || resp.StatusCode == http.StatusNotImplemented:
+			err = errSyncUnsupported
+		default:
+			err = fmt.Errorf("sync-collection: %s", resp.Status)
+		}
+		return
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		err = errSyncUnsupported
+		return
+	}
+
+	var ms syncMultiStatus
+	err = xml.NewDecoder(resp.Body).Decode(&ms)
+	if err != nil {
+		return changes, fmt.Errorf("decode sync-collection: %w", err)
+	}
+
+	changes = syncChanges{
+		Changed:   map[string]string{},
+		Full:      syncToken == "",
+		SyncToken: ms.SyncToken,
+	}
+	calHref := c.resolveHref(calPath)
+	for _, r := range ms.Responses {
+		var href *url.URL
+		href, err = calHref.Parse(r.Href)
+		if err != nil {
+			return changes, fmt.Errorf("decode sync-collection: %w", err)
+		}
+		objPath := href.Path
+		if strings.TrimSuffix(objPath, "/") == strings.TrimSuffix(calHref.Path, "/") {
+			continue
+		}
+		if statusCode(r.Status) == http.StatusNotFound {
+			changes.Removed = append(changes.Removed, objPath)
+			continue
+		}
+		for _, ps := range r.PropStat {
+			if statusCode(ps.Status)/100 != 2 {
+				continue
+			}
+			changes.Changed[objPath] = unquoteETag(ps.ETag)
+		}
+		if _, ok := changes.Changed[objPath]; !ok {
+			// the etag is optional, objects without one are always fetched
+			changes.Changed[objPath] = ""
+		}
+	}
+	return changes, nil
+}
+
+// statusCode parses the code of a status line like "HTTP/1.1 404 Not Found".
+func statusCode(status string) int {
+	fields := strings.Fields(status)
+	if len(fields) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return code
+}
+
+func unquoteETag(etag string) string {
+	etag = strings.TrimSpace(etag)
+	if unquoted, err := strconv.Unquote(etag); err == nil {
+		return unquoted
+	}
+	return etag
+}
@@ -0,0 +1,257 @@
+package calendar
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCaldav is a minimal caldav collection that supports sync-collection,
+// calendar-multiget and PROPFIND.
+type fakeCaldav struct {
+	mutex       sync.Mutex
+	noSync      bool
+	token       int
+	objects     map[string]fakeObject
+	removed     map[string]int
+	multiGets   [][]string
+	syncTokens  []string
+	unreachable bool
+}
+
+type fakeObject struct {
+	etag     string
+	data     string
+	modified int
+}
+
+func newFakeCaldav() *fakeCaldav {
+	return &fakeCaldav{
+		objects: map[string]fakeObject{},
+		removed: map[string]int{},
+	}
+}
+
+func (f *fakeCaldav) put(path, etag, data string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.token++
+	f.objects[path] = fakeObject{etag: etag, data: data, modified: f.token}
+}
+
+func (f *fakeCaldav) remove(path string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.token++
+	delete(f.objects, path)
+	f.removed[path] = f.token
+}
+
+func (f *fakeCaldav) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.unreachable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="utf-8"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+
+	switch {
+	case r.Method == "REPORT" && strings.Contains(string(body), "sync-collection"):
+		if f.noSync {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		var query struct {
+			SyncToken string `xml:"sync-token"`
+		}
+		xml.Unmarshal(body, &query)
+		f.syncTokens = append(f.syncTokens, query.SyncToken)
+		since := 0
+		if query.SyncToken != "" {
+			fmt.Sscanf(query.SyncToken, "token-%d", &since)
+		}
+		for path, obj := range f.objects {
+			if obj.modified > since {
+				fmt.Fprintf(&out, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>"%s"</d:getetag></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, path, obj.etag)
+			}
+		}
+		for path, token := range f.removed {
+			if since != 0 && token > since {
+				fmt.Fprintf(&out, `<d:response><d:href>%s</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>`, path)
+			}
+		}
+		fmt.Fprintf(&out, `<d:sync-token>token-%d</d:sync-token>`, f.token)
+	case r.Method == "REPORT" && strings.Contains(string(body), "calendar-multiget"):
+		var query struct {
+			Hrefs []string `xml:"href"`
+		}
+		xml.Unmarshal(body, &query)
+		f.multiGets = append(f.multiGets, query.Hrefs)
+		for _, href := range query.Hrefs {
+			obj := f.objects[href]
+			fmt.Fprintf(&out, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>"%s"</d:getetag><c:calendar-data>%s</c:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, href, obj.etag, obj.data)
+		}
+	case r.Method == "PROPFIND":
+		fmt.Fprintf(&out, `<d:response><d:href>/cal/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		for path, obj := range f.objects {
+			fmt.Fprintf(&out, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype/><d:getetag>"%s"</d:getetag><d:getcontenttype>text/calendar</d:getcontenttype><d:getcontentlength>%d</d:getcontentlength></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, path, obj.etag, len(obj.data))
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	out.WriteString(`</d:multistatus>`)
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, out.String())
+}
+
+func singleObject(uid, summary string) string {
+	return strings.ReplaceAll(fmt.Sprintf(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//calstats//test//EN
+BEGIN:VEVENT
+UID:%s
+DTSTAMP:20240101T000000Z
+SUMMARY:%s
+DTSTART:20240102T100000Z
+DTEND:20240102T110000Z
+END:VEVENT
+END:VCALENDAR
+`, uid, summary), "\n", "\r\n")
+}
+
+func eventNames(t *testing.T, client Caldav) []string {
+	t.Helper()
+	events, err := client.Events(
+		context.Background(),
+		Calendar{Id: "/cal/"},
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC),
+		time.UTC,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestCaldavSync(t *testing.T) {
+	fake := newFakeCaldav()
+	fake.put("/cal/a.ics", "a1", singleObject("a", "Alpha"))
+	fake.put("/cal/b.ics", "b1", singleObject("b", "Beta"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	client, err := NewCaldav(server.URL, CaldavOptions{CacheDir: cacheDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := eventNames(t, client)
+	if !slices.Equal(names, []string{"Alpha", "Beta"}) {
+		t.Fatalf("unexpected events after initial sync: %v", names)
+	}
+
+	fake.put("/cal/a.ics", "a2", singleObject("a", "Alpha 2"))
+	fake.remove("/cal/b.ics")
+	fake.put("/cal/c.ics", "c1", singleObject("c", "Gamma"))
+
+	names = eventNames(t, client)
+	if !slices.Equal(names, []string{"Alpha 2", "Gamma"}) {
+		t.Fatalf("unexpected events after incremental sync: %v", names)
+	}
+	if fake.syncTokens[1] != "token-2" {
+		t.Fatalf("expected incremental sync with token-2, got %q", fake.syncTokens[1])
+	}
+	if got := fake.multiGets[1]; !slices.Equal(got, []string{"/cal/a.ics", "/cal/c.ics"}) {
+		t.Fatalf("expected only changed objects to be fetched, got %v", got)
+	}
+
+	// a new client with the same cache dir works from the last synced state
+	// while the server is unreachable
+	fake.unreachable = true
+	offline, err := NewCaldav(server.URL, CaldavOptions{CacheDir: cacheDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = eventNames(t, offline)
+	if !slices.Equal(names, []string{"Alpha 2", "Gamma"}) {
+		t.Fatalf("unexpected events while offline: %v", names)
+	}
+}
+
+func TestCaldavSyncEtagFallback(t *testing.T) {
+	fake := newFakeCaldav()
+	fake.noSync = true
+	fake.put("/cal/a.ics", "a1", singleObject("a", "Alpha"))
+	fake.put("/cal/b.ics", "b1", singleObject("b", "Beta"))
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := NewCaldav(server.URL, CaldavOptions{CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := eventNames(t, client)
+	if !slices.Equal(names, []string{"Alpha", "Beta"}) {
+		t.Fatalf("unexpected events after initial sync: %v", names)
+	}
+
+	fake.put("/cal/b.ics", "b2", singleObject("b", "Beta 2"))
+	fake.remove("/cal/a.ics")
+
+	names = eventNames(t, client)
+	if !slices.Equal(names, []string{"Beta 2"}) {
+		t.Fatalf("unexpected events after etag sync: %v", names)
+	}
+	if got := fake.multiGets[1]; !slices.Equal(got, []string{"/cal/b.ics"}) {
+		t.Fatalf("expected only changed objects to be fetched, got %v", got)
+	}
+}
+
+func TestCachedObjectSpan(t *testing.T) {
+	recurring := strings.ReplaceAll(singleObject("r", "Recurring"), "DTEND:20240102T110000Z\r\n", "DTEND:20240102T110000Z\r\nRRULE:FREQ=DAILY\r\n")
+	tests := []struct {
+		data       string
+		start, end time.Time
+		ok         bool
+	}{
+		{
+			data:  singleObject("a", "Alpha"),
+			start: time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, time.January, 3, 11, 0, 0, 0, time.UTC),
+			ok:    true,
+		},
+		{data: recurring},
+		{data: "not a calendar"},
+	}
+	for _, test := range tests {
+		obj := &cachedObject{Data: test.data}
+		start, end, ok := obj.span()
+		if ok != test.ok || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("expected (%v, %v, %v), got (%v, %v, %v) for\n%s", test.start, test.end, test.ok, start, end, ok, test.data)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// tzResolver resolves the TZIDs used in a calendar object to locations. TZIDs
+// are looked up in the IANA database first, then as Windows zone names or
+// aliases, and finally the VTIMEZONE definitions embedded in the calendar
+// object are used.
+type tzResolver struct {
+	vtimezones map[string]*ical.Component
+	locations  map[string]*time.Location
+}
+
+// newTzResolver creates a resolver for the given calendar object, cal may be
+// nil if the object has no VTIMEZONE components.
+func newTzResolver(cal *ical.Calendar) *tzResolver {
+	r := &tzResolver{
+		vtimezones: map[string]*ical.Component{},
+		locations:  map[string]*time.Location{},
+	}
+	if cal == nil {
+		return r
+	}
+	for _, child := range cal.Children {
+		if child.Name != ical.CompTimezone {
+			continue
+		}
+		tzId, err := child.Props.Text(ical.PropTimezoneID)
+		if err != nil || tzId == "" {
+			continue
+		}
+		r.vtimezones[tzId] = child
+	}
+	return r
+}
+
+// location returns the location with the given TZID.
+func (r *tzResolver) location(tzId string) (*time.Location, error) {
+	if loc, ok := r.locations[tzId]; ok {
+		return loc, nil
+	}
+
+	loc, ok := lookupZone(tzId)
+	if !ok {
+		vtimezone, found := r.vtimezones[tzId]
+		if !found {
+			return nil, fmt.Errorf("unknown timezone '%s'", tzId)
+		}
+		// X-LIC-LOCATION is set by Mozilla clients to the IANA name of a
+		// custom timezone
+		if lic, err := vtimezone.Props.Text("X-LIC-LOCATION"); err == nil && lic != "" {
+			loc, ok = lookupZone(lic)
+		}
+		if !ok {
+			var err error
+			loc, err = vtimezoneLocation(tzId, vtimezone)
+			if err != nil {
+				return nil, fmt.Errorf("timezone '%s': %w", tzId, err)
+			}
+		}
+	}
+	r.locations[tzId] = loc
+	return loc, nil
+}
+
+// lookupZone looks up a zone by its IANA name, Windows name or alias. It also
+// accepts globally unique TZIDs that end with an IANA name, like
+// "/mozilla.org/20050126_1/Europe/Berlin".
+func lookupZone(tzId string) (*time.Location, bool) {
+	name := strings.Trim(strings.TrimSpace(tzId), `"`)
+	if name == "" || name == "Local" {
+		return nil, false
+	}
+
+	candidates := []string{name}
+	if iana, ok := windowsZones[name]; ok {
+		candidates = append(candidates, iana)
+	}
+	if strings.HasPrefix(name, "/") {
+		parts := strings.Split(name, "/")
+		for i := 1; i < len(parts); i++ {
+			candidates = append(candidates, strings.Join(parts[i:], "/"))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		names := []string{candidate}
+		if alias, ok := zoneAliases[candidate]; ok {
+			names = append(names, alias)
+		}
+		for _, n := range names {
+			loc, err := time.LoadLocation(n)
+			if err == nil {
+				return loc, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// dateTime parses a DATE or DATE-TIME property, floating values and dates are
+// interpreted in tz.
+func (r *tzResolver) dateTime(prop *ical.Prop, tz *time.Location) (time.Time, error) {
+	return r.parseDateValue(prop.Value, prop.ValueType(), prop.Params.Get(ical.PropTimezoneID), tz)
+}
+
+// parseDateValue parses a single DATE or DATE-TIME value, floating values and
+// dates are interpreted in tz.
+func (r *tzResolver) parseDateValue(value string, valueType ical.ValueType, tzId string, tz *time.Location) (time.Time, error) {
+	if tz == nil {
+		tz = time.UTC
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case valueType == ical.ValueDate || len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, tz)
+	case strings.HasSuffix(value, "Z"):
+		return time.ParseInLocation("20060102T150405Z", value, time.UTC)
+	}
+	loc := tz
+	if tzId != "" {
+		var err error
+		loc, err = r.location(tzId)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// eventStart returns the start of the event.
+func (r *tzResolver) eventStart(e ical.Event, tz *time.Location) (time.Time, error) {
+	startProp := e.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		return time.Time{}, fmt.Errorf("missing start")
+	}
+	return r.dateTime(startProp, tz)
+}
+
+// eventEnd returns the non-inclusive end of the event, which is given by
+// DTEND, DTSTART and DURATION, or the end of the start day for all-day events.
+func (r *tzResolver) eventEnd(e ical.Event, tz *time.Location) (time.Time, error) {
+	if endProp := e.Props.Get(ical.PropDateTimeEnd); endProp != nil {
+		return r.dateTime(endProp, tz)
+	}
+	startProp := e.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		return time.Time{}, nil
+	}
+	start, err := r.dateTime(startProp, tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if durProp := e.Props.Get(ical.PropDuration); durProp != nil {
+		dur, err := durProp.Duration()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return start.Add(dur), nil
+	}
+	if startProp.ValueType() == ical.ValueDate || len(startProp.Value) == len("20060102") {
+		return start.AddDate(0, 0, 1), nil
+	}
+	return start, nil
+}
+
+type observance struct {
+	start      time.Time
+	offsetFrom int
+	offsetTo   int
+	rrule      string
+}
+
+// vtimezoneLocation creates a location from the current rules of a
+// VTIMEZONE, that is its latest STANDARD and DAYLIGHT observances.
+func vtimezoneLocation(name string, vtimezone *ical.Component) (*time.Location, error) {
+	var standard, daylight *observance
+	for _, child := range vtimezone.Children {
+		if child.Name != ical.CompTimezoneStandard && child.Name != ical.CompTimezoneDaylight {
+			continue
+		}
+		obs, err := parseObservance(child)
+		if err != nil {
+			return nil, err
+		}
+		latest := &standard
+		if child.Name == ical.CompTimezoneDaylight {
+			latest = &daylight
+		}
+		if *latest == nil || obs.start.After((*latest).start) {
+			*latest = &obs
+		}
+	}
+	if standard == nil && daylight == nil {
+		return nil, fmt.Errorf("no observances")
+	}
+	if standard == nil {
+		return time.FixedZone(name, daylight.offsetTo), nil
+	}
+	// daylight saving time that was abolished or is not given by a rule
+	// cannot be expressed as the current rule of the zone
+	if daylight == nil || daylight.rrule == "" || strings.Contains(strings.ToUpper(daylight.rrule), "UNTIL=") {
+		return time.FixedZone(name, standard.offsetTo), nil
+	}
+
+	stdRule, err := posixRule(standard)
+	if err != nil {
+		return nil, err
+	}
+	dstRule, err := posixRule(daylight)
+	if err != nil {
+		return nil, err
+	}
+	tz := fmt.Sprintf(
+		"%s%s%s%s,%s,%s",
+		posixName(standard.offsetTo), posixOffset(standard.offsetTo),
+		posixName(daylight.offsetTo), posixOffset(daylight.offsetTo),
+		dstRule, stdRule,
+	)
+	return time.LoadLocationFromTZData(name, tzif(tz, standard.offsetTo))
+}
+
+func parseObservance(comp *ical.Component) (obs observance, err error) {
+	startProp := comp.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		err = fmt.Errorf("observance without start")
+		return
+	}
+	obs.start, err = time.ParseInLocation("20060102T150405", startProp.Value, time.UTC)
+	if err != nil {
+		return
+	}
+	obs.offsetFrom, err = parseUtcOffset(comp.Props.Get(ical.PropTimezoneOffsetFrom))
+	if err != nil {
+		return
+	}
+	obs.offsetTo, err = parseUtcOffset(comp.Props.Get(ical.PropTimezoneOffsetTo))
+	if err != nil {
+		return
+	}
+	if rruleProp := comp.Props.Get(ical.PropRecurrenceRule); rruleProp != nil {
+		obs.rrule = rruleProp.Value
+	}
+	return
+}
+
+// parseUtcOffset parses a UTC-OFFSET value like "+0100" or "-053000" into
+// seconds east of UTC.
+func parseUtcOffset(prop *ical.Prop) (int, error) {
+	if prop == nil {
+		return 0, fmt.Errorf("missing utc offset")
+	}
+	value := strings.TrimSpace(prop.Value)
+	if len(value) != 5 && len(value) != 7 {
+		return 0, fmt.Errorf("invalid utc offset '%s'", value)
+	}
+	sign := 1
+	switch value[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, fmt.Errorf("invalid utc offset '%s'", value)
+	}
+	var parts [3]int
+	for i := 0; i*2+1 < len(value); i++ {
+		n, err := strconv.Atoi(value[i*2+1 : i*2+3])
+		if err != nil {
+			return 0, fmt.Errorf("invalid utc offset '%s'", value)
+		}
+		parts[i] = n
+	}
+	return sign * (parts[0]*3600 + parts[1]*60 + parts[2]), nil
+}
+
+// posixRule converts the yearly RRULE of an observance into a POSIX TZ rule
+// like "M3.5.0/2".
+func posixRule(obs *observance) (string, error) {
+	var month, week, weekday int
+	var monthDays []int
+	for _, part := range strings.Split(strings.ToUpper(obs.rrule), ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			if value != "YEARLY" {
+				return "", fmt.Errorf("unsupported observance rule '%s'", obs.rrule)
+			}
+		case "BYMONTH":
+			month, _ = strconv.Atoi(value)
+		case "BYDAY":
+			if len(value) < 2 {
+				return "", fmt.Errorf("unsupported observance rule '%s'", obs.rrule)
+			}
+			idx := strings.Index("SUMOTUWETHFRSA", value[len(value)-2:])
+			if idx < 0 || idx%2 != 0 {
+				return "", fmt.Errorf("unsupported observance rule '%s'", obs.rrule)
+			}
+			weekday = idx / 2
+			if n := value[:len(value)-2]; n != "" {
+				week, _ = strconv.Atoi(n)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, _ := strconv.Atoi(d)
+				monthDays = append(monthDays, n)
+			}
+		}
+	}
+	// "the first sunday on or after the 8th" is written as BYDAY=SU with
+	// BYMONTHDAY=8,9,...,14
+	if week == 0 && len(monthDays) > 0 && monthDays[0] > 0 {
+		week = (monthDays[0]-1)/7 + 1
+	}
+	if week < 0 {
+		week = 5
+	}
+	if month < 1 || month > 12 || week < 1 || week > 5 {
+		return "", fmt.Errorf("unsupported observance rule '%s'", obs.rrule)
+	}
+
+	secs := obs.start.Hour()*3600 + obs.start.Minute()*60 + obs.start.Second()
+	return fmt.Sprintf("M%d.%d.%d/%s", month, week, weekday, posixTime(secs)), nil
+}
+
+// posixName returns a quoted POSIX TZ abbreviation for the offset.
+func posixName(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("<%c%02d%02d>", sign, offset/3600, offset/60%60)
+}
+
+// posixOffset formats an offset in seconds east of UTC as a POSIX TZ offset,
+// which is west of UTC.
+func posixOffset(offset int) string {
+	if offset > 0 {
+		return "-" + posixTime(offset)
+	}
+	return posixTime(-offset)
+}
+
+func posixTime(secs int) string {
+	return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+// tzif encodes a TZif file without transitions, so that the POSIX TZ string
+// in its footer applies at all times.
+func tzif(tz string, stdOffset int) []byte {
+	const abbr = "STD\x00"
+	var buf bytes.Buffer
+	header := func() {
+		buf.WriteString("TZif2")
+		buf.Write(make([]byte, 15))
+		// isutcnt, isstdcnt, leapcnt, timecnt, typecnt, charcnt
+		for _, n := range []uint32{0, 0, 0, 0, 1, uint32(len(abbr))} {
+			binary.Write(&buf, binary.BigEndian, n)
+		}
+	}
+	data := func() {
+		binary.Write(&buf, binary.BigEndian, int32(stdOffset))
+		buf.WriteByte(0) // isdst
+		buf.WriteByte(0) // abbreviation index
+		buf.WriteString(abbr)
+	}
+	header()
+	data()
+	header()
+	data()
+	buf.WriteString("\n" + tz + "\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+const outlookObject = `BEGIN:VCALENDAR
+PRODID:-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Customized Time Zone
+BEGIN:STANDARD
+DTSTART:16011028T030000
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010325T020000
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:(UTC-05:00) Eastern Time (US & Canada)
+BEGIN:STANDARD
+DTSTART:16011104T020000
+RRULE:FREQ=YEARLY;BYDAY=SU;BYMONTHDAY=1,2,3,4,5,6,7;BYMONTH=11
+TZOFFSETFROM:-0400
+TZOFFSETTO:-0500
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010311T020000
+RRULE:FREQ=YEARLY;BYDAY=SU;BYMONTHDAY=8,9,10,11,12,13,14;BYMONTH=3
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0400
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:India
+BEGIN:STANDARD
+DTSTART:16010101T000000
+TZOFFSETFROM:+0530
+TZOFFSETTO:+0530
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:winter
+SUMMARY:Winter
+DTSTART;TZID=Customized Time Zone:20240115T090000
+DTEND;TZID=Customized Time Zone:20240115T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:summer
+SUMMARY:Summer
+DTSTART;TZID=Customized Time Zone:20240715T090000
+DTEND;TZID=Customized Time Zone:20240715T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:eastern
+SUMMARY:Eastern
+DTSTART;TZID="(UTC-05:00) Eastern Time (US & Canada)":20240312T090000
+DTEND;TZID="(UTC-05:00) Eastern Time (US & Canada)":20240312T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:india
+SUMMARY:India
+DTSTART;TZID=India:20240715T090000
+DTEND;TZID=India:20240715T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:windows
+SUMMARY:Windows
+DTSTART;TZID=Pacific Standard Time:20240715T090000
+DTEND;TZID=Pacific Standard Time:20240715T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:mozilla
+SUMMARY:Mozilla
+DTSTART;TZID=/mozilla.org/20050126_1/Europe/Berlin:20240115T090000
+DTEND;TZID=/mozilla.org/20050126_1/Europe/Berlin:20240115T100000
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestTimezoneResolver(t *testing.T) {
+	cal, err := ical.NewDecoder(strings.NewReader(strings.ReplaceAll(outlookObject, "\n", "\r\n"))).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tzr := newTzResolver(cal)
+
+	expect := map[string]string{
+		"Winter":  "2024-01-15T08:00:00Z",
+		"Summer":  "2024-07-15T07:00:00Z",
+		"Eastern": "2024-03-12T13:00:00Z",
+		"India":   "2024-07-15T03:30:00Z",
+		"Windows": "2024-07-15T16:00:00Z",
+		"Mozilla": "2024-01-15T08:00:00Z",
+	}
+	intvEnd := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range cal.Events() {
+		parsed, err := parseEvent(e, tzr, intvEnd, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := parsed.Start.UTC().Format(time.RFC3339)
+		if got != expect[parsed.Name] {
+			t.Errorf("%s: expected start %s, got %s", parsed.Name, expect[parsed.Name], got)
+		}
+		if parsed.Duration != time.Hour {
+			t.Errorf("%s: expected a duration of 1h, got %v", parsed.Name, parsed.Duration)
+		}
+	}
+
+	_, err = tzr.location("Nowhere/Unknown")
+	if err == nil {
+		t.Fatal("expected an unknown timezone to be rejected")
+	}
+}
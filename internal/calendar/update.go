@@ -0,0 +1,363 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// groupUpdatesByPath groups updates by the path of the calendar object that
+// contains the updated event, the paths are returned in the order they first
+// appear in.
+func groupUpdatesByPath(ids map[uint64]eventId, updates []UpdateEvent) (paths []string, byPath map[string][]UpdateEvent, err error) {
+	byPath = map[string][]UpdateEvent{}
+	for _, update := range updates {
+		id, ok := ids[update.Id]
+		if !ok {
+			err = fmt.Errorf("update event: unknown event id %d", update.Id)
+			return
+		}
+		if _, ok := byPath[id.Path]; !ok {
+			paths = append(paths, id.Path)
+		}
+		byPath[id.Path] = append(byPath[id.Path], update)
+	}
+	return
+}
+
+// applyUpdates applies the updates to the events they refer to in the
+// calendar object, ids[i] must be the id of updates[i]. Overrides are created
+// for recurrence instances that do not have one yet.
+func applyUpdates(cal *ical.Calendar, ids []eventId, updates []UpdateEvent) error {
+	tzr := newTzResolver(cal)
+	for i, update := range updates {
+		id := ids[i]
+		comp, err := findEventComponent(cal, tzr, id.Uid, id.RId)
+		if err != nil {
+			return err
+		}
+		if comp == nil && id.ShouldOverride {
+			master, err := findEventComponent(cal, tzr, id.Uid, time.Time{})
+			if err != nil {
+				return err
+			}
+			if master == nil {
+				return fmt.Errorf("original event of '%s' not found", id.Uid)
+			}
+			comp, err = createOverride(cal, tzr, master, id.RId)
+			if err != nil {
+				return err
+			}
+		}
+		if comp == nil {
+			return fmt.Errorf("event '%s' not found", id.Uid)
+		}
+
+		err = applyUpdate(comp, tzr, update)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markOverridden marks the updated events as no longer needing an override
+// to be created, as one was created when they were updated.
+func markOverridden(ids map[uint64]eventId, updates []UpdateEvent) {
+	for _, update := range updates {
+		id := ids[update.Id]
+		id.ShouldOverride = false
+		ids[update.Id] = id
+	}
+}
+
+// findEventComponent finds the VEVENT in the given calendar object that
+// matches the given UID and recurrence ID. A zero recurrence ID matches the
+// original (master) event.
+func findEventComponent(cal *ical.Calendar, tzr *tzResolver, uid string, rid time.Time) (*ical.Component, error) {
+	for _, child := range cal.Children {
+		if child.Name != ical.CompEvent {
+			continue
+		}
+		uidProp := child.Props.Get(ical.PropUID)
+		if uidProp == nil || uidProp.Value != uid {
+			continue
+		}
+
+		ridProp := child.Props.Get(ical.PropRecurrenceID)
+		if ridProp == nil || ridProp.Value == "" {
+			if rid.IsZero() {
+				return child, nil
+			}
+			continue
+		}
+		if rid.IsZero() {
+			continue
+		}
+		childRid, err := tzr.dateTime(ridProp, rid.Location())
+		if err != nil {
+			return nil, err
+		}
+		if childRid.Equal(rid) {
+			return child, nil
+		}
+	}
+	return nil, nil
+}
+
+// createOverride creates a new override (an event with a RECURRENCE-ID) for
+// the recurrence instance of master that starts at rid and appends it to the
+// calendar object.
+func createOverride(cal *ical.Calendar, tzr *tzResolver, master *ical.Component, rid time.Time) (*ical.Component, error) {
+	startProp := master.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		return nil, fmt.Errorf("create override: original event has no start")
+	}
+
+	masterEvent := ical.Event{Component: master}
+	start, err := tzr.eventStart(masterEvent, rid.Location())
+	if err != nil {
+		return nil, fmt.Errorf("create override: %w", err)
+	}
+	end, err := tzr.eventEnd(masterEvent, rid.Location())
+	if err != nil {
+		return nil, fmt.Errorf("create override: %w", err)
+	}
+
+	override := ical.NewComponent(ical.CompEvent)
+	for name, props := range master.Props {
+		switch name {
+		case ical.PropRecurrenceRule,
+			ical.PropRecurrenceDates,
+			ical.PropExceptionDates,
+			ical.PropDateTimeStart,
+			ical.PropDateTimeEnd,
+			ical.PropDuration:
+			continue
+		}
+		override.Props[name] = append([]ical.Prop(nil), props...)
+	}
+	for _, child := range master.Children {
+		override.Children = append(override.Children, cloneComponent(child))
+	}
+
+	ridProp := ical.NewProp(ical.PropRecurrenceID)
+	err = setDateTimeLike(tzr, ridProp, startProp, rid)
+	if err != nil {
+		return nil, fmt.Errorf("create override: %w", err)
+	}
+	override.Props.Set(ridProp)
+
+	overrideStart := ical.NewProp(ical.PropDateTimeStart)
+	err = setDateTimeLike(tzr, overrideStart, startProp, rid)
+	if err != nil {
+		return nil, fmt.Errorf("create override: %w", err)
+	}
+	override.Props.Set(overrideStart)
+
+	overrideEnd := ical.NewProp(ical.PropDateTimeEnd)
+	err = setDateTimeLike(tzr, overrideEnd, startProp, rid.Add(end.Sub(start)))
+	if err != nil {
+		return nil, fmt.Errorf("create override: %w", err)
+	}
+	override.Props.Set(overrideEnd)
+
+	cal.Children = append(cal.Children, override)
+	return override, nil
+}
+
+func cloneComponent(comp *ical.Component) *ical.Component {
+	clone := ical.NewComponent(comp.Name)
+	for name, props := range comp.Props {
+		clone.Props[name] = append([]ical.Prop(nil), props...)
+	}
+	for _, child := range comp.Children {
+		clone.Children = append(clone.Children, cloneComponent(child))
+	}
+	return clone
+}
+
+// setDateTimeLike sets the value of dst to t, using the same value type and
+// timezone as like. This keeps DATE values as dates, TZID values in their
+// original timezone, UTC values in UTC and floating values floating.
+func setDateTimeLike(tzr *tzResolver, dst, like *ical.Prop, t time.Time) error {
+	if like == nil {
+		dst.SetDateTime(t.In(time.UTC))
+		return nil
+	}
+	if like.ValueType() == ical.ValueDate || len(like.Value) == len("20060102") {
+		dst.SetDate(t)
+		return nil
+	}
+	if tzId := like.Params.Get(ical.PropTimezoneID); tzId != "" {
+		loc, err := tzr.location(tzId)
+		if err != nil {
+			return err
+		}
+		// keep the original TZID, which may refer to a VTIMEZONE in the object
+		dst.SetValueType(ical.ValueDateTime)
+		dst.Params.Set(ical.PropTimezoneID, tzId)
+		dst.Value = formatICalDatetime(t.In(loc))
+		return nil
+	}
+	if len(like.Value) == len("20060102T150405Z") {
+		dst.SetDateTime(t.In(time.UTC))
+		return nil
+	}
+	// floating time
+	dst.SetValueType(ical.ValueDateTime)
+	dst.Params.Del(ical.PropTimezoneID)
+	dst.Value = formatICalDatetime(t)
+	return nil
+}
+
+// applyUpdate applies the fields set in the update to the given VEVENT. The
+// SEQUENCE is incremented and DTSTAMP refreshed, so other clients pick up the
+// change.
+func applyUpdate(comp *ical.Component, tzr *tzResolver, update UpdateEvent) error {
+	err := validateTimes(comp, tzr, update)
+	if err != nil {
+		return err
+	}
+
+	if update.Name != nil {
+		comp.Props.SetText(ical.PropSummary, *update.Name)
+	}
+	if update.Location != nil {
+		if *update.Location == "" {
+			comp.Props.Del(ical.PropLocation)
+		} else {
+			comp.Props.SetText(ical.PropLocation, *update.Location)
+		}
+	}
+	if update.Description != nil {
+		if *update.Description == "" {
+			comp.Props.Del(ical.PropDescription)
+		} else {
+			comp.Props.SetText(ical.PropDescription, *update.Description)
+		}
+	}
+	if update.Tags != nil {
+		if len(*update.Tags) == 0 {
+			comp.Props.Del(ical.PropCategories)
+		} else {
+			prop := ical.NewProp(ical.PropCategories)
+			prop.SetTextList(*update.Tags)
+			comp.Props.Set(prop)
+		}
+	}
+
+	startProp := comp.Props.Get(ical.PropDateTimeStart)
+	if update.Start != nil {
+		prop := ical.NewProp(ical.PropDateTimeStart)
+		err := setDateTimeLike(tzr, prop, startProp, *update.Start)
+		if err != nil {
+			return fmt.Errorf("set start: %w", err)
+		}
+		comp.Props.Set(prop)
+	}
+	if update.End != nil {
+		like := comp.Props.Get(ical.PropDateTimeEnd)
+		if like == nil {
+			like = startProp
+		}
+		prop := ical.NewProp(ical.PropDateTimeEnd)
+		err := setDateTimeLike(tzr, prop, like, *update.End)
+		if err != nil {
+			return fmt.Errorf("set end: %w", err)
+		}
+		comp.Props.Del(ical.PropDuration)
+		comp.Props.Set(prop)
+	}
+
+	if update.Trigger != nil {
+		applyTrigger(comp, *update.Trigger)
+	}
+
+	sequence := 0
+	if prop := comp.Props.Get(ical.PropSequence); prop != nil {
+		sequence, err = prop.Int()
+		if err != nil {
+			return fmt.Errorf("parse sequence: %w", err)
+		}
+	}
+	prop := ical.NewProp(ical.PropSequence)
+	prop.Value = strconv.Itoa(sequence + 1)
+	comp.Props.Set(prop)
+	comp.Props.SetDateTime(ical.PropDateTimeStamp, time.Now().UTC())
+	return nil
+}
+
+// validateTimes checks that the event still ends after it starts with the
+// times of the update. Events without an end stay without one, so moving
+// their start can't make them negative.
+func validateTimes(comp *ical.Component, tzr *tzResolver, update UpdateEvent) error {
+	if update.Start == nil && update.End == nil {
+		return nil
+	}
+	hasEnd := comp.Props.Get(ical.PropDateTimeEnd) != nil || comp.Props.Get(ical.PropDuration) != nil
+	if update.End == nil && !hasEnd {
+		return nil
+	}
+
+	event := ical.Event{Component: comp}
+	start, end := update.Start, update.End
+	if start == nil {
+		current, err := tzr.eventStart(event, time.UTC)
+		if err != nil {
+			return fmt.Errorf("get start: %w", err)
+		}
+		start = &current
+	}
+	if end == nil {
+		current, err := tzr.eventEnd(event, time.UTC)
+		if err != nil {
+			return fmt.Errorf("get end: %w", err)
+		}
+		end = &current
+	}
+	if !end.After(*start) {
+		return fmt.Errorf("end %s is not after start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// applyTrigger sets the trigger of the first VALARM of the event, creating
+// one if needed. A trigger that is neither absolute nor relative removes all
+// alarms from the event.
+func applyTrigger(comp *ical.Component, trigger EventTrigger) {
+	if trigger.Absolute.IsZero() && !trigger.NotNone {
+		var children []*ical.Component
+		for _, child := range comp.Children {
+			if child.Name != ical.CompAlarm {
+				children = append(children, child)
+			}
+		}
+		comp.Children = children
+		return
+	}
+
+	var alarm *ical.Component
+	for _, child := range comp.Children {
+		if child.Name == ical.CompAlarm {
+			alarm = child
+			break
+		}
+	}
+	if alarm == nil {
+		alarm = ical.NewComponent(ical.CompAlarm)
+		alarm.Props.SetText(ical.PropAction, "DISPLAY")
+		alarm.Props.SetText(ical.PropDescription, "Reminder")
+		comp.Children = append(comp.Children, alarm)
+	}
+
+	prop := ical.NewProp(ical.PropTrigger)
+	if !trigger.Absolute.IsZero() {
+		prop.SetDateTime(trigger.Absolute.In(time.UTC))
+	} else {
+		prop.SetDuration(trigger.Relative)
+	}
+	alarm.Props.Set(prop)
+}
@@ -0,0 +1,176 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+const recurringObject = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//calstats//test//EN
+BEGIN:VEVENT
+UID:standup
+DTSTAMP:20240101T000000Z
+SUMMARY:Standup
+DTSTART;TZID=America/New_York:20240101T090000
+DTEND;TZID=America/New_York:20240101T091500
+RRULE:FREQ=DAILY;COUNT=5
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestCaldavUpdate(t *testing.T) {
+	var put []byte
+	var ifMatch string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", ical.MIMEType)
+			w.Header().Set("ETag", `"v1"`)
+			io.WriteString(w, strings.ReplaceAll(recurringObject, "\n", "\r\n"))
+		case http.MethodPut:
+			ifMatch = r.Header.Get("If-Match")
+			put, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewCaldav(server.URL, CaldavOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rid := time.Date(2024, time.January, 3, 9, 0, 0, 0, ny)
+	id := instanceId("standup", rid)
+	client.ids.events[id] = eventId{
+		Path:           "/calendars/user/work/standup.ics",
+		Uid:            "standup",
+		RId:            rid,
+		ShouldOverride: true,
+	}
+
+	name := "Planning"
+	tags := []string{"work", "meetings"}
+	err = client.Update(context.Background(), []UpdateEvent{{
+		Id:   id,
+		Name: &name,
+		Tags: &tags,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ifMatch != `"v1"` {
+		t.Fatalf("expected If-Match \"v1\", got %q", ifMatch)
+	}
+	cal, err := ical.NewDecoder(bytes.NewReader(put)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := cal.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected original event and override, got %d events", len(events))
+	}
+
+	override := events[1]
+	ridProp := override.Props.Get(ical.PropRecurrenceID)
+	if ridProp == nil {
+		t.Fatal("override has no RECURRENCE-ID")
+	}
+	if ridProp.Value != "20240103T090000" || ridProp.Params.Get(ical.PropTimezoneID) != "America/New_York" {
+		t.Fatalf("unexpected RECURRENCE-ID: %+v", ridProp)
+	}
+	if override.Props.Get(ical.PropRecurrenceRule) != nil {
+		t.Fatal("override should not have an RRULE")
+	}
+	if summary, _ := override.Props.Text(ical.PropSummary); summary != name {
+		t.Fatalf("expected summary %q, got %q", name, summary)
+	}
+	categories, _ := override.Props.Get(ical.PropCategories).TextList()
+	if strings.Join(categories, ",") != "work,meetings" {
+		t.Fatalf("unexpected categories: %v", categories)
+	}
+	end, err := override.DateTimeEnd(ny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !end.Equal(rid.Add(15 * time.Minute)) {
+		t.Fatalf("expected override to end at %s, got %s", rid.Add(15*time.Minute), end)
+	}
+	if summary, _ := events[0].Props.Text(ical.PropSummary); summary != "Standup" {
+		t.Fatalf("original event should not be modified, got summary %q", summary)
+	}
+
+	if client.ids.events[id].ShouldOverride {
+		t.Fatal("expected instance to be marked as overridden after update")
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hour int) *time.Time {
+		t := time.Date(2024, time.January, 1, hour, 0, 0, 0, ny)
+		return &t
+	}
+
+	tests := []struct {
+		name       string
+		update     UpdateEvent
+		expectFail bool
+	}{
+		{"rename", UpdateEvent{Name: new(string)}, false},
+		{"move", UpdateEvent{Start: at(10), End: at(11)}, false},
+		{"end before start", UpdateEvent{Start: at(10), End: at(9)}, true},
+		{"empty", UpdateEvent{Start: at(10), End: at(10)}, true},
+		{"start after the current end", UpdateEvent{Start: at(10)}, true},
+		{"end before the current start", UpdateEvent{End: at(8)}, true},
+	}
+	for _, test := range tests {
+		cal, err := ical.NewDecoder(strings.NewReader(strings.ReplaceAll(recurringObject, "\n", "\r\n"))).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		comp := cal.Events()[0].Component
+		err = applyUpdate(comp, newTzResolver(cal), test.update)
+		if test.expectFail {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if sequence, err := comp.Props.Get(ical.PropSequence).Int(); err != nil || sequence != 1 {
+			t.Errorf("%s: expected SEQUENCE 1, got %d (%v)", test.name, sequence, err)
+		}
+		stamp, err := comp.Props.DateTime(ical.PropDateTimeStamp, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if time.Since(stamp) > time.Minute {
+			t.Errorf("%s: expected DTSTAMP to be refreshed, got %s", test.name, stamp)
+		}
+	}
+}
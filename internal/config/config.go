@@ -2,25 +2,157 @@ package config
 
 import (
 	"calstats/internal/calendar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 type Source struct {
 	Server    Server   `json:"server"`    // Server configuration.
+	Path      string   `json:"path"`      // Path to a .ics file or a vdir directory, use this instead of server to read calendars from disk.
 	Calendars []string `json:"calendars"` // Specify the calendars you want to include by their names.
+	AllDay    AllDay   `json:"all_day"`   // How all-day events are counted.
+}
+
+type AllDayPolicy string
+
+const (
+	AllDayCount      AllDayPolicy = "count"      // Count every day of the event as 24 hours.
+	AllDayExclude    AllDayPolicy = "exclude"    // Leave all-day events out.
+	AllDayHours      AllDayPolicy = "hours"      // Count every day of the event as a fixed amount of hours.
+	AllDayBackground AllDayPolicy = "background" // Show all-day events as labels that don't consume time.
+)
+
+type AllDay struct {
+	Policy AllDayPolicy `json:"policy"` // One of "count" (default), "exclude", "hours" or "background".
+	Hours  float64      `json:"hours"`  // Hours counted per day with the "hours" policy.
+}
+
+func (cfg AllDay) validate() error {
+	switch cfg.Policy {
+	case "", AllDayCount, AllDayExclude, AllDayBackground:
+	case AllDayHours:
+		if cfg.Hours <= 0 || cfg.Hours > 24 {
+			return fmt.Errorf("all_day: hours must be within (0, 24], got %v", cfg.Hours)
+		}
+	default:
+		return fmt.Errorf("all_day: unknown policy '%s'", cfg.Policy)
+	}
+	return nil
+}
+
+func (cfg Source) Source() (source calendar.Source, err error) {
+	if cfg.Path != "" && cfg.Server.Url != "" {
+		err = fmt.Errorf("source must have either a server or a path, not both")
+		return
+	}
+	err = cfg.AllDay.validate()
+	if err != nil {
+		return
+	}
+	if cfg.Path != "" {
+		var path string
+		path, err = expandHome(cfg.Path)
+		if err != nil {
+			err = fmt.Errorf("path: %w", err)
+			return
+		}
+		source, err = calendar.NewLocal(path)
+		return
+	}
+	return cfg.Server.Source()
+}
+
+// expandHome replaces a leading "~/" in the path with the home directory of
+// the user.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
+// Origin returns the server url or path the source reads calendars from, a
+// password in the url is redacted.
+func (cfg Source) Origin() string {
+	if cfg.Path != "" {
+		return cfg.Path
+	}
+	return redactUrl(cfg.Server.Url)
+}
+
+// Rule assigns tags to the events it matches, rules are tried in order and
+// the first matching rule applies.
+type Rule struct {
+	Name     string    `json:"name"`     // Shown by the rules command, defaults to the position of the rule.
+	Match    RuleMatch `json:"match"`    // Conditions on the event, every set condition must match. A rule without conditions matches every event.
+	Tags     []string  `json:"tags"`     // Tags assigned to matching events.
+	Override bool      `json:"override"` // Also apply the rule to events that have CATEGORIES, replacing them.
+}
+
+// RuleMatch holds the patterns of a rule. Patterns are globs (* and ?)
+// matching the whole value ignoring case, or RE2 regular expressions when
+// prefixed with "re:", like "re:(?i)^standup".
+type RuleMatch struct {
+	Name        string `json:"name"`        // Pattern for the event name.
+	Location    string `json:"location"`    // Pattern for the event location.
+	Description string `json:"description"` // Pattern for the event description.
+	Calendar    string `json:"calendar"`    // Pattern for the name of the calendar.
+	Source      string `json:"source"`      // Pattern for the server url or path of the source.
+}
+
+// FreeBusy decides which events make you busy in the free/busy endpoints.
+type FreeBusy struct {
+	Rules   []FreeBusyRule `json:"rules"`   // Types of events by their tags, for events with several tags the busiest type applies.
+	Default string         `json:"default"` // Type of events no rule matches, defaults to "busy".
+}
+
+// FreeBusyRule sets the free/busy type of the events with a tag.
+type FreeBusyRule struct {
+	Tag  string `json:"tag"`  // Tag of the events, a tag also matches the categories below it like "work" matches "work/meetings". Untagged events have the tag "Unknown".
+	Type string `json:"type"` // One of "free", "busy", "busy-tentative" or "busy-unavailable".
 }
 
 type Server struct {
-	Url      string `json:"url"`      // Specify the principal url of the caldav server, that is the caldav server + the user.
-	Insecure bool   `json:"insecure"` // Ignore HTTPS issues.
-	Username string `json:"username"` // Authentication username.
-	Password string `json:"password"` // Authentication password.
+	Url             string `json:"url"`              // Specify the principal url of the caldav server, that is the caldav server + the user.
+	Feed            bool   `json:"feed"`             // Treat the url as a subscribed iCalendar feed (.ics url) instead of a caldav server, this is implied by webcal:// urls.
+	Insecure        bool   `json:"insecure"`         // Ignore HTTPS issues.
+	Username        string `json:"username"`         // Authentication username.
+	Password        string `json:"password"`         // Authentication password, prefer one of the alternatives below to keep it out of the config.
+	PasswordFile    string `json:"password_file"`    // Read the password from this file.
+	PasswordEnv     string `json:"password_env"`     // Read the password from this environment variable.
+	PasswordCommand string `json:"password_command"` // Use the first line printed by this shell command as the password, like "pass show caldav".
+	CacheDir        string `json:"cache_dir"`        // Sync calendar objects incrementally into this directory so they stay available offline, they are stored unencrypted. Without it every request queries the server.
 }
 
 func (cfg Server) Source() (source calendar.Source, err error) {
+	password, err := cfg.password()
+	if err != nil {
+		return
+	}
+	if cfg.Feed || strings.HasPrefix(cfg.Url, "webcal://") || strings.HasPrefix(cfg.Url, "webcals://") {
+		source, err = calendar.NewFeed(cfg.Url, calendar.FeedOptions{
+			Username: cfg.Username,
+			Password: password,
+			Insecure: cfg.Insecure,
+		})
+		return
+	}
+	cacheDir, err := expandHome(cfg.CacheDir)
+	if err != nil {
+		err = fmt.Errorf("cache_dir: %w", err)
+		return
+	}
 	source, err = calendar.NewCaldav(cfg.Url, calendar.CaldavOptions{
 		Username: cfg.Username,
-		Password: cfg.Password,
+		Password: password,
 		Insecure: cfg.Insecure,
+		CacheDir: cacheDir,
 	})
 	return
 }
\ No newline at end of file
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// password resolves the password of the server from the one of password,
+// password_file, password_env or password_command that is set. Errors never
+// contain the password itself.
+func (cfg Server) password() (string, error) {
+	var set []string
+	for name, value := range map[string]string{
+		"password":         cfg.Password,
+		"password_file":    cfg.PasswordFile,
+		"password_env":     cfg.PasswordEnv,
+		"password_command": cfg.PasswordCommand,
+	} {
+		if value != "" {
+			set = append(set, name)
+		}
+	}
+	if len(set) > 1 {
+		return "", fmt.Errorf("server: set only one of password, password_file, password_env and password_command")
+	}
+
+	switch {
+	case cfg.PasswordFile != "":
+		return passwordFromFile(cfg.PasswordFile)
+	case cfg.PasswordEnv != "":
+		password, ok := os.LookupEnv(cfg.PasswordEnv)
+		if !ok || password == "" {
+			return "", fmt.Errorf("password_env: environment variable %s is not set", cfg.PasswordEnv)
+		}
+		return password, nil
+	case cfg.PasswordCommand != "":
+		return passwordFromCommand(cfg.PasswordCommand)
+	default:
+		return cfg.Password, nil
+	}
+}
+
+// passwordFromFile reads the password from the file at path, a trailing
+// newline is not part of the password.
+func passwordFromFile(path string) (string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", fmt.Errorf("password_file: %w", err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("password_file: %w", err)
+	}
+	password := strings.TrimRight(string(contents), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("password_file: %s is empty", path)
+	}
+	return password, nil
+}
+
+// passwordFromCommand runs the command with the shell and uses the first line
+// of its output as the password, like the output of "pass show".
+func passwordFromCommand(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// only stderr is reported, stdout may hold the password
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && msg != "" {
+			return "", fmt.Errorf("password_command '%s': %w: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("password_command '%s': %w", command, err)
+	}
+	password, _, _ := strings.Cut(string(out), "\n")
+	password = strings.TrimRight(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("password_command '%s': no output", command)
+	}
+	return password, nil
+}
+
+// redactUrl hides the password in the user info of the url. Urls that can't
+// be parsed are replaced entirely, they may still contain a password.
+func redactUrl(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid url>"
+	}
+	if u.User == nil {
+		return raw
+	}
+	return u.Redacted()
+}
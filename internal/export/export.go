@@ -0,0 +1,168 @@
+// Package export writes events as flat rows for spreadsheets and data
+// analysis tools.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"   // Comma separated values with a header, tags are separated by ";".
+	FormatJSONL Format = "jsonl" // A JSON object per line.
+)
+
+// ParseFormat validates the name of a format.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatCSV, FormatJSONL:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s'", name)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/jsonl; charset=utf-8"
+}
+
+// Row is an event with everything resolved, parts of an event split by an
+// overlap policy share the event id.
+type Row struct {
+	EventId     int
+	Source      string
+	Calendar    string
+	Name        string
+	Location    string
+	Description string
+	Tags        []string
+	Start, End  time.Time
+	Duration    time.Duration // The counted duration, which differs from the time between start and end for all-day events.
+	AllDay      bool
+	Background  bool // All-day events shown as labels, their time isn't counted.
+}
+
+// jsonRow is a row in the jsonl format, the keys match the csv columns.
+type jsonRow struct {
+	EventId         int       `json:"event_id"`
+	Source          string    `json:"source"`
+	Calendar        string    `json:"calendar"`
+	Name            string    `json:"name"`
+	Location        string    `json:"location"`
+	Description     string    `json:"description"`
+	Tags            []string  `json:"tags"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	AllDay          bool      `json:"all_day"`
+	Background      bool      `json:"background"`
+}
+
+// header holds the csv columns.
+var header = []string{
+	"event_id",
+	"source",
+	"calendar",
+	"name",
+	"location",
+	"description",
+	"tags",
+	"start",
+	"end",
+	"duration_seconds",
+	"all_day",
+	"background",
+}
+
+// Writer writes rows in a format.
+type Writer struct {
+	csv    *csv.Writer
+	json   *json.Encoder
+	header bool
+}
+
+// NewWriter returns a writer for the format.
+func NewWriter(w io.Writer, format Format) *Writer {
+	if format == FormatCSV {
+		return &Writer{csv: csv.NewWriter(w)}
+	}
+	return &Writer{json: json.NewEncoder(w)}
+}
+
+// Write writes a row, times are written in RFC 3339 with their offset and
+// durations in seconds.
+func (w *Writer) Write(row Row) error {
+	if w.json != nil {
+		tags := row.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		return w.json.Encode(jsonRow{
+			EventId:         row.EventId,
+			Source:          row.Source,
+			Calendar:        row.Calendar,
+			Name:            row.Name,
+			Location:        row.Location,
+			Description:     row.Description,
+			Tags:            tags,
+			Start:           row.Start,
+			End:             row.End,
+			DurationSeconds: row.Duration.Seconds(),
+			AllDay:          row.AllDay,
+			Background:      row.Background,
+		})
+	}
+
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	return w.csv.Write([]string{
+		strconv.Itoa(row.EventId),
+		row.Source,
+		row.Calendar,
+		row.Name,
+		row.Location,
+		row.Description,
+		strings.Join(row.Tags, ";"),
+		row.Start.Format(time.RFC3339),
+		row.End.Format(time.RFC3339),
+		strconv.FormatFloat(row.Duration.Seconds(), 'f', -1, 64),
+		strconv.FormatBool(row.AllDay),
+		strconv.FormatBool(row.Background),
+	})
+}
+
+// writeHeader writes the csv header once, so an export without rows still
+// has its columns.
+func (w *Writer) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.csv.Write(header)
+}
+
+// Flush writes any buffered rows, it must be called after the last row.
+func (w *Writer) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.FixedZone("", 3600))
+	rows := []Row{
+		{
+			EventId:  0,
+			Source:   "https://dav.example.com/me/",
+			Calendar: "Work",
+			Name:     `Review "PRJ-1", part 1`,
+			Tags:     []string{"work/review", "meeting:0.5"},
+			Start:    start,
+			End:      start.Add(90 * time.Minute),
+			Duration: 90 * time.Minute,
+		},
+		{
+			EventId:    1,
+			Calendar:   "Private",
+			Name:       "Holiday",
+			Start:      start,
+			End:        start.Add(24 * time.Hour),
+			AllDay:     true,
+			Background: true,
+		},
+	}
+
+	table := []struct {
+		format Format
+		expect string
+	}{
+		{FormatCSV, "event_id,source,calendar,name,location,description,tags,start,end,duration_seconds,all_day,background\n" +
+			`0,https://dav.example.com/me/,Work,"Review ""PRJ-1"", part 1",,,work/review;meeting:0.5,2024-01-01T09:00:00+01:00,2024-01-01T10:30:00+01:00,5400,false,false` + "\n" +
+			"1,,Private,Holiday,,,,2024-01-01T09:00:00+01:00,2024-01-02T09:00:00+01:00,0,true,true\n"},
+		{FormatJSONL, `{"event_id":0,"source":"https://dav.example.com/me/","calendar":"Work","name":"Review \"PRJ-1\", part 1","location":"","description":"","tags":["work/review","meeting:0.5"],"start":"2024-01-01T09:00:00+01:00","end":"2024-01-01T10:30:00+01:00","duration_seconds":5400,"all_day":false,"background":false}` + "\n" +
+			`{"event_id":1,"source":"","calendar":"Private","name":"Holiday","location":"","description":"","tags":[],"start":"2024-01-01T09:00:00+01:00","end":"2024-01-02T09:00:00+01:00","duration_seconds":0,"all_day":true,"background":true}` + "\n"},
+	}
+	for _, test := range table {
+		var b strings.Builder
+		w := NewWriter(&b, test.format)
+		for _, row := range rows {
+			err := w.Write(row)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := w.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.expect {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.format, test.expect, b.String())
+		}
+	}
+}
+
+func TestWriterEmptyCSV(t *testing.T) {
+	var b strings.Builder
+	err := NewWriter(&b, FormatCSV).Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "event_id,") {
+		t.Errorf("expected the header, got '%s'", b.String())
+	}
+}
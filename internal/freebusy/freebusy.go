@@ -0,0 +1,189 @@
+// Package freebusy computes free/busy time from events and answers RFC 5545
+// VFREEBUSY requests, sharing availability without the details of events.
+package freebusy
+
+import (
+	"calstats/internal/config"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Type is a free/busy type, busier types have larger values.
+type Type int
+
+const (
+	Free Type = iota
+	BusyTentative
+	Busy
+	BusyUnavailable
+)
+
+// ParseType parses a type in the lowercase form of the config, like
+// "busy-tentative".
+func ParseType(name string) (Type, error) {
+	switch name {
+	case "free":
+		return Free, nil
+	case "busy-tentative":
+		return BusyTentative, nil
+	case "busy":
+		return Busy, nil
+	case "busy-unavailable":
+		return BusyUnavailable, nil
+	default:
+		return Free, fmt.Errorf("unknown free/busy type '%s'", name)
+	}
+}
+
+// String returns the FBTYPE parameter value of the type.
+func (t Type) String() string {
+	switch t {
+	case BusyTentative:
+		return "BUSY-TENTATIVE"
+	case Busy:
+		return "BUSY"
+	case BusyUnavailable:
+		return "BUSY-UNAVAILABLE"
+	default:
+		return "FREE"
+	}
+}
+
+type rule struct {
+	tag string
+	typ Type
+}
+
+// Classifier decides the type of events by their tags.
+type Classifier struct {
+	rules     []rule
+	fallback  Type
+	separator string
+}
+
+// NewClassifier validates the config, separator is the category separator
+// that lets rules match the categories below their tag.
+func NewClassifier(cfg config.FreeBusy, separator string) (Classifier, error) {
+	out := Classifier{fallback: Busy, separator: separator}
+	if cfg.Default != "" {
+		fallback, err := ParseType(cfg.Default)
+		if err != nil {
+			return out, fmt.Errorf("free_busy default: %w", err)
+		}
+		out.fallback = fallback
+	}
+	for i, r := range cfg.Rules {
+		if r.Tag == "" {
+			return out, fmt.Errorf("free_busy rule #%d: tag is required", i+1)
+		}
+		typ, err := ParseType(r.Type)
+		if err != nil {
+			return out, fmt.Errorf("free_busy rule #%d: %w", i+1, err)
+		}
+		out.rules = append(out.rules, rule{tag: r.Tag, typ: typ})
+	}
+	return out, nil
+}
+
+// matches is true if the tag is the tag of the rule or a category below it.
+func (c Classifier) matches(r rule, tag string) bool {
+	if strings.EqualFold(tag, r.tag) {
+		return true
+	}
+	prefix := r.tag + c.separator
+	return c.separator != "" && len(tag) > len(prefix) && strings.EqualFold(tag[:len(prefix)], prefix)
+}
+
+// Type returns the busiest type of the tags, the first rule matching a tag
+// decides its type.
+func (c Classifier) Type(tags []string) Type {
+	out := Free
+	for _, tag := range tags {
+		typ := c.fallback
+		for _, r := range c.rules {
+			if c.matches(r, tag) {
+				typ = r.typ
+				break
+			}
+		}
+		out = max(out, typ)
+	}
+	return out
+}
+
+// Period is a span of time of a type.
+type Period struct {
+	Start, End time.Time
+	Type       Type
+}
+
+// Merge clips the periods to the interval and merges them into sorted,
+// non-overlapping periods, where periods overlap the busiest type wins. Free
+// time is left out.
+func Merge(periods []Period, start, end time.Time) []Period {
+	type boundary struct {
+		at    time.Time
+		typ   Type
+		delta int
+	}
+	var boundaries []boundary
+	for _, p := range periods {
+		pStart, pEnd := maxTime(p.Start, start), minTime(p.End, end)
+		if p.Type == Free || !pStart.Before(pEnd) {
+			continue
+		}
+		boundaries = append(boundaries, boundary{pStart, p.Type, 1}, boundary{pEnd, p.Type, -1})
+	}
+	slices.SortFunc(boundaries, func(a, b boundary) int {
+		return a.at.Compare(b.at)
+	})
+
+	// active counts the periods of each type covering the current time
+	var active [BusyUnavailable + 1]int
+	busiest := func() Type {
+		for t := BusyUnavailable; t > Free; t-- {
+			if active[t] > 0 {
+				return t
+			}
+		}
+		return Free
+	}
+
+	var out []Period
+	for i := 0; i < len(boundaries); {
+		at := boundaries[i].at
+		for ; i < len(boundaries) && boundaries[i].at.Equal(at); i++ {
+			active[boundaries[i].typ] += boundaries[i].delta
+		}
+		typ := busiest()
+
+		// the last period is still open until its end is set
+		if n := len(out); n > 0 && out[n-1].End.IsZero() {
+			if out[n-1].Type == typ {
+				continue
+			}
+			out[n-1].End = at
+		}
+		if typ != Free {
+			out = append(out, Period{Start: at, Type: typ})
+		}
+	}
+	// every period is closed at the last boundary, where nothing is active
+	return out
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
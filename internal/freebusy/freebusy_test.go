@@ -0,0 +1,144 @@
+package freebusy
+
+import (
+	"calstats/internal/config"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+func TestClassifier(t *testing.T) {
+	c, err := NewClassifier(config.FreeBusy{
+		Rules: []config.FreeBusyRule{
+			{Tag: "work/focus", Type: "busy-unavailable"},
+			{Tag: "work", Type: "busy"},
+			{Tag: "Maybe", Type: "busy-tentative"},
+		},
+		Default: "free",
+	}, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tags   []string
+		expect Type
+	}{
+		{nil, Free},
+		{[]string{"private"}, Free},
+		{[]string{"work"}, Busy},
+		{[]string{"Work/meetings"}, Busy},
+		{[]string{"workshop"}, Free},
+		{[]string{"work/focus/deep"}, BusyUnavailable},
+		{[]string{"maybe"}, BusyTentative},
+		{[]string{"maybe", "work"}, Busy},
+	}
+	for _, test := range tests {
+		if got := c.Type(test.tags); got != test.expect {
+			t.Errorf("%v: expected %v, got %v", test.tags, test.expect, got)
+		}
+	}
+
+	for _, cfg := range []config.FreeBusy{
+		{Default: "away"},
+		{Rules: []config.FreeBusyRule{{Type: "busy"}}},
+		{Rules: []config.FreeBusyRule{{Tag: "work", Type: "BUSY"}}},
+	} {
+		if _, err := NewClassifier(cfg, "/"); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, time.January, 1, hour, 0, 0, 0, time.UTC)
+	}
+	periods := []Period{
+		{at(9), at(11), Busy},
+		{at(10), at(12), BusyTentative},
+		{at(10), at(11), BusyUnavailable},
+		// touching periods of the same type are merged
+		{at(12), at(13), BusyTentative},
+		{at(14), at(15), Free},
+		// clipped to the interval
+		{at(16), at(20), Busy},
+		{at(20), at(22), Busy},
+	}
+	expect := []Period{
+		{at(9), at(10), Busy},
+		{at(10), at(11), BusyUnavailable},
+		{at(11), at(13), BusyTentative},
+		{at(16), at(18), Busy},
+	}
+	got := Merge(periods, at(8), at(18))
+	if !slices.Equal(got, expect) {
+		t.Errorf("expected %v, got %v", expect, got)
+	}
+}
+
+func TestReply(t *testing.T) {
+	request := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//test//EN",
+		"METHOD:REQUEST",
+		"BEGIN:VFREEBUSY",
+		"UID:request-1",
+		"DTSTAMP:20240101T000000Z",
+		"DTSTART:20240101T080000Z",
+		"DTEND:20240101T180000Z",
+		"ORGANIZER:mailto:alice@example.com",
+		"ATTENDEE:mailto:bob@example.com",
+		"END:VFREEBUSY",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	cal, err := ical.NewDecoder(strings.NewReader(request)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := ParseRequest(cal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, time.January, 1, 10, 0, 0, 0, time.FixedZone("", 3600))
+	periods := []Period{{start, start.Add(time.Hour), BusyTentative}}
+	var b strings.Builder
+	err = ical.NewEncoder(&b).Encode(Reply(req, periods, start))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"METHOD:REPLY",
+		"UID:request-1",
+		"DTSTART:20240101T080000Z",
+		"ORGANIZER:mailto:alice@example.com",
+		"ATTENDEE:mailto:bob@example.com",
+		"FREEBUSY;FBTYPE=BUSY-TENTATIVE:20240101T090000Z/20240101T100000Z",
+	} {
+		if !strings.Contains(b.String(), line+"\r\n") {
+			t.Errorf("expected %s in\n%s", line, b.String())
+		}
+	}
+
+	for _, component := range []string{
+		"BEGIN:VEVENT\r\nUID:1\r\nDTSTAMP:20240101T000000Z\r\nEND:VEVENT",
+		"BEGIN:VFREEBUSY\r\nUID:1\r\nDTSTAMP:20240101T000000Z\r\nDTSTART:20240101T080000Z\r\nEND:VFREEBUSY",
+		"BEGIN:VFREEBUSY\r\nUID:1\r\nDTSTAMP:20240101T000000Z\r\nDTSTART:20240101T080000Z\r\nDTEND:20240101T070000Z\r\nEND:VFREEBUSY",
+		"BEGIN:VFREEBUSY\r\nUID:1\r\nDTSTAMP:20240101T000000Z\r\nDTSTART:20240101T080000Z\r\nDTEND:20260101T080000Z\r\nEND:VFREEBUSY",
+	} {
+		body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//test//EN\r\n" + component + "\r\nEND:VCALENDAR\r\n"
+		cal, err := ical.NewDecoder(strings.NewReader(body)).Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseRequest(cal); err == nil {
+			t.Errorf("expected an error for\n%s", component)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package freebusy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emersion/go-ical"
+)
+
+// MaxRange limits the length of the time range of a request.
+const MaxRange = 366 * 24 * time.Hour
+
+const utcFormat = "20060102T150405Z"
+
+// Request is a VFREEBUSY request for the busy time in a range.
+type Request struct {
+	Uid        string
+	Start, End time.Time
+	// Organizer and Attendees are copied into the reply.
+	Organizer *ical.Prop
+	Attendees []ical.Prop
+}
+
+// ParseRequest reads the VFREEBUSY component of a request, it must have a
+// DTSTART and DTEND no more than MaxRange apart.
+func ParseRequest(cal *ical.Calendar) (req Request, err error) {
+	var comp *ical.Component
+	for _, child := range cal.Children {
+		if child.Name == ical.CompFreeBusy {
+			comp = child
+			break
+		}
+	}
+	if comp == nil {
+		return req, fmt.Errorf("request has no %s component", ical.CompFreeBusy)
+	}
+
+	req.Start, err = comp.Props.DateTime(ical.PropDateTimeStart, time.UTC)
+	if err != nil || req.Start.IsZero() {
+		return req, fmt.Errorf("parse %s: %w", ical.PropDateTimeStart, missing(err))
+	}
+	req.End, err = comp.Props.DateTime(ical.PropDateTimeEnd, time.UTC)
+	if err != nil || req.End.IsZero() {
+		return req, fmt.Errorf("parse %s: %w", ical.PropDateTimeEnd, missing(err))
+	}
+	if !req.Start.Before(req.End) {
+		return req, fmt.Errorf("%s must be before %s", ical.PropDateTimeStart, ical.PropDateTimeEnd)
+	}
+	if req.End.Sub(req.Start) > MaxRange {
+		return req, fmt.Errorf("range is longer than %v", MaxRange)
+	}
+
+	req.Uid, _ = comp.Props.Text(ical.PropUID)
+	req.Organizer = comp.Props.Get(ical.PropOrganizer)
+	req.Attendees = comp.Props.Values(ical.PropAttendee)
+	return req, nil
+}
+
+// missing returns err, or an error for a property that isn't set.
+func missing(err error) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("property is required")
+}
+
+// Reply answers the request with the busy periods.
+func Reply(req Request, periods []Period, now time.Time) *ical.Calendar {
+	uid := req.Uid
+	if uid == "" {
+		uid = rangeUid(req.Start, req.End)
+	}
+	comp := component(uid, req.Start, req.End, periods, now)
+	if req.Organizer != nil {
+		comp.Props.Set(req.Organizer)
+	}
+	for _, attendee := range req.Attendees {
+		comp.Props.Add(&attendee)
+	}
+	return calendar("REPLY", comp)
+}
+
+// Publish returns a calendar publishing the busy periods of the range.
+func Publish(periods []Period, start, end time.Time, now time.Time) *ical.Calendar {
+	return calendar("PUBLISH", component(rangeUid(start, end), start, end, periods, now))
+}
+
+// rangeUid identifies the free/busy time of a range.
+func rangeUid(start, end time.Time) string {
+	return fmt.Sprintf("freebusy-%s-%s@calstats", start.UTC().Format(utcFormat), end.UTC().Format(utcFormat))
+}
+
+func calendar(method string, comp *ical.Component) *ical.Calendar {
+	cal := ical.NewCalendar()
+	cal.Props.SetText(ical.PropVersion, "2.0")
+	cal.Props.SetText(ical.PropProductID, "-//calstats//calstats//EN")
+	cal.Props.SetText(ical.PropMethod, method)
+	cal.Children = append(cal.Children, comp)
+	return cal
+}
+
+// component returns a VFREEBUSY component with a FREEBUSY property per
+// period, times are written in UTC as RFC 5545 requires.
+func component(uid string, start, end time.Time, periods []Period, now time.Time) *ical.Component {
+	comp := ical.NewComponent(ical.CompFreeBusy)
+	comp.Props.SetText(ical.PropUID, uid)
+	comp.Props.SetDateTime(ical.PropDateTimeStamp, now.UTC())
+	comp.Props.SetDateTime(ical.PropDateTimeStart, start.UTC())
+	comp.Props.SetDateTime(ical.PropDateTimeEnd, end.UTC())
+	for _, p := range periods {
+		prop := ical.NewProp(ical.PropFreeBusy)
+		prop.Params.Set(ical.ParamFreeBusyType, p.Type.String())
+		prop.Value = p.Start.UTC().Format(utcFormat) + "/" + p.End.UTC().Format(utcFormat)
+		comp.Props.Add(prop)
+	}
+	return comp
+}
@@ -0,0 +1,46 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Presets are the intervals offered by the interval select of the UI.
+var Presets = []string{
+	"THIS_DAY",
+	"THIS_WEEK",
+	"THIS_MONTH",
+	"THIS_YEAR",
+	"LAST_3_MONTHS",
+	"LAST_6_MONTHS",
+}
+
+// ResolvePreset returns the interval of the preset at now, in the timezone of
+// now. The "THIS_" presets cover the whole day, week (starting on monday),
+// month or year, the "LAST_" presets end at now. The end is exclusive.
+func ResolvePreset(preset string, now time.Time) (start, end time.Time, err error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	switch strings.ToUpper(preset) {
+	case "THIS_DAY":
+		return today, today.AddDate(0, 0, 1), nil
+	case "THIS_WEEK":
+		// time.Weekday starts on sunday
+		start = today.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7), nil
+	case "THIS_MONTH":
+		start = time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	case "THIS_YEAR":
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(1, 0, 0), nil
+	case "LAST_3_MONTHS":
+		return today.AddDate(0, -3, 0), now, nil
+	case "LAST_6_MONTHS":
+		return today.AddDate(0, -6, 0), now, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown interval '%s', expected one of %s", preset, strings.Join(Presets, ", "))
+	}
+}
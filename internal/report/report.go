@@ -0,0 +1,205 @@
+// Package report renders category statistics for the terminal and for other
+// programs.
+package report
+
+import (
+	v1 "calstats/api/v1"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatTable    Format = "table"    // Aligned columns with bar charts.
+	FormatJSON     Format = "json"     // A single JSON object.
+	FormatCSV      Format = "csv"      // One row per category with a header.
+	FormatMarkdown Format = "markdown" // A markdown table.
+)
+
+// ParseFormat validates the name of a format.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatTable, FormatJSON, FormatCSV, FormatMarkdown:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s'", name)
+	}
+}
+
+// barWidth is the width of the longest bar in characters.
+const barWidth = 30
+
+// Render writes the categories of the stats in the format, disabled
+// categories are left out like on the dashboard.
+func Render(w io.Writer, stats *v1.StatsResponse, format Format) error {
+	var categories []*v1.CategoryStat
+	for _, c := range stats.Categories {
+		if !c.Disabled {
+			categories = append(categories, c)
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		return renderJSON(w, stats, categories)
+	case FormatCSV:
+		return renderCSV(w, categories)
+	case FormatMarkdown:
+		return renderMarkdown(w, stats, categories)
+	default:
+		return renderTable(w, stats, categories)
+	}
+}
+
+func renderTable(w io.Writer, stats *v1.StatsResponse, categories []*v1.CategoryStat) error {
+	// bars are scaled to the largest category so small differences stay
+	// visible, the proportion column holds the share of the total
+	var longest float64
+	for _, c := range categories {
+		longest = max(longest, c.Proportion)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tTIME\tSHARE\tEVENTS\t")
+	for _, c := range categories {
+		var bar string
+		if longest > 0 {
+			bar = Bar(c.Proportion/longest, barWidth)
+		}
+		fmt.Fprintf(
+			tw, "%s\t%s\t%s\t%d\t%s\n",
+			c.Name,
+			FormatDuration(c.Time.AsDuration()),
+			formatPercent(c.Proportion),
+			c.Events,
+			bar,
+		)
+	}
+	fmt.Fprintf(tw, "Total\t%s\t\t\t\n", FormatDuration(stats.Total.AsDuration()))
+	return tw.Flush()
+}
+
+func renderMarkdown(w io.Writer, stats *v1.StatsResponse, categories []*v1.CategoryStat) error {
+	escape := strings.NewReplacer("|", `\|`)
+
+	var b strings.Builder
+	b.WriteString("| Category | Time | Share | Events |\n")
+	b.WriteString("| --- | ---: | ---: | ---: |\n")
+	for _, c := range categories {
+		fmt.Fprintf(
+			&b, "| %s | %s | %s | %d |\n",
+			escape.Replace(c.Name),
+			FormatDuration(c.Time.AsDuration()),
+			formatPercent(c.Proportion),
+			c.Events,
+		)
+	}
+	fmt.Fprintf(&b, "| **Total** | %s | | |\n", FormatDuration(stats.Total.AsDuration()))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderCSV writes the time in seconds and the proportion as a fraction, so
+// the columns can be used in calculations.
+func renderCSV(w io.Writer, categories []*v1.CategoryStat) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"category", "seconds", "proportion", "events"})
+	for _, c := range categories {
+		cw.Write([]string{
+			c.Name,
+			strconv.FormatFloat(c.Time.AsDuration().Seconds(), 'f', -1, 64),
+			strconv.FormatFloat(c.Proportion, 'f', -1, 64),
+			strconv.FormatUint(uint64(c.Events), 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonCategory struct {
+	Name       string  `json:"name"`
+	Seconds    float64 `json:"seconds"`
+	Proportion float64 `json:"proportion"`
+	Events     uint32  `json:"events"`
+}
+
+type jsonReport struct {
+	Categories       []jsonCategory `json:"categories"`
+	TotalSeconds     float64        `json:"total_seconds"`
+	TrackedSeconds   float64        `json:"tracked_seconds"`
+	UntrackedSeconds float64        `json:"untracked_seconds"`
+}
+
+func renderJSON(w io.Writer, stats *v1.StatsResponse, categories []*v1.CategoryStat) error {
+	out := jsonReport{
+		Categories:       make([]jsonCategory, len(categories)),
+		TotalSeconds:     stats.Total.AsDuration().Seconds(),
+		TrackedSeconds:   stats.Tracked.AsDuration().Seconds(),
+		UntrackedSeconds: stats.Untracked.AsDuration().Seconds(),
+	}
+	for i, c := range categories {
+		out.Categories[i] = jsonCategory{
+			Name:       c.Name,
+			Seconds:    c.Time.AsDuration().Seconds(),
+			Proportion: c.Proportion,
+			Events:     c.Events,
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func formatPercent(proportion float64) string {
+	return strconv.FormatFloat(proportion*100, 'f', 1, 64) + "%"
+}
+
+// blocks holds the partial blocks from one to seven eighths.
+var blocks = []rune("▏▎▍▌▋▊▉")
+
+// Bar draws the fraction of width characters with unicode blocks, with eighth
+// character precision.
+func Bar(fraction float64, width int) string {
+	eighths := int(min(max(fraction, 0), 1)*float64(width*8) + 0.5)
+	bar := strings.Repeat("█", eighths/8)
+	if eighths%8 > 0 {
+		bar += string(blocks[eighths%8-1])
+	}
+	return bar
+}
+
+// FormatDuration formats the duration like formatDuration in the UI, with
+// the two largest units from years down to minutes, like "2 days 3 hours".
+// Durations below a minute are empty.
+func FormatDuration(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{365 * 24 * time.Hour, "year"},
+		{7 * 24 * time.Hour, "week"},
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+
+	var out []string
+	for _, unit := range units {
+		n := d / unit.size
+		d -= n * unit.size
+		switch {
+		case n == 1:
+			out = append(out, "1 "+unit.name)
+		case n > 1:
+			out = append(out, fmt.Sprintf("%d %ss", n, unit.name))
+		}
+	}
+	return strings.Join(out[:min(len(out), 2)], " ")
+}
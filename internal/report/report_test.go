@@ -0,0 +1,138 @@
+package report
+
+import (
+	v1 "calstats/api/v1"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestFormatDuration(t *testing.T) {
+	table := []struct {
+		duration time.Duration
+		expect   string
+	}{
+		{30 * time.Second, ""},
+		{time.Minute, "1 minute"},
+		{90 * time.Minute, "1 hour 30 minutes"},
+		// only the two largest units are shown
+		{26*time.Hour + 5*time.Minute, "1 day 2 hours"},
+		{8*24*time.Hour + 5*time.Minute, "1 week 1 day"},
+		{15 * 24 * time.Hour, "2 weeks 1 day"},
+		{2*365*24*time.Hour + 3*time.Hour, "2 years 3 hours"},
+	}
+	for _, test := range table {
+		got := FormatDuration(test.duration)
+		if got != test.expect {
+			t.Errorf("%v: expected '%s', got '%s'", test.duration, test.expect, got)
+		}
+	}
+}
+
+func TestBar(t *testing.T) {
+	table := []struct {
+		fraction float64
+		expect   string
+	}{
+		{0, ""},
+		{1, "████"},
+		{0.5, "██"},
+		{0.25 + 1.0/32, "█▏"},
+		{0.3125, "█▎"},
+		{2, "████"},
+	}
+	for _, test := range table {
+		got := Bar(test.fraction, 4)
+		if got != test.expect {
+			t.Errorf("%v: expected '%s', got '%s'", test.fraction, test.expect, got)
+		}
+	}
+}
+
+func TestResolvePreset(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, berlin)
+	}
+	// a sunday and the day daylight saving time starts
+	now := time.Date(2024, time.March, 31, 15, 30, 0, 0, berlin)
+	// the last day of a year and a leap day on a thursday
+	newYearsEve := time.Date(2024, time.December, 31, 9, 0, 0, 0, berlin)
+	leapDay := time.Date(2024, time.February, 29, 9, 0, 0, 0, berlin)
+
+	// the interval options of the UI resolve to the same bounds, with the
+	// end moved a nanosecond back to be inclusive
+	table := []struct {
+		preset     string
+		now        time.Time
+		start, end time.Time
+	}{
+		{"THIS_DAY", now, date(2024, time.March, 31), date(2024, time.April, 1)},
+		{"this_week", now, date(2024, time.March, 25), date(2024, time.April, 1)},
+		{"THIS_MONTH", now, date(2024, time.March, 1), date(2024, time.April, 1)},
+		{"THIS_YEAR", now, date(2024, time.January, 1), date(2025, time.January, 1)},
+		// days past the end of the month overflow into the next one
+		{"LAST_3_MONTHS", now, date(2023, time.December, 31), now},
+		{"LAST_6_MONTHS", now, date(2023, time.October, 1), now},
+		{"THIS_WEEK", newYearsEve, date(2024, time.December, 30), date(2025, time.January, 6)},
+		{"THIS_MONTH", newYearsEve, date(2024, time.December, 1), date(2025, time.January, 1)},
+		{"THIS_YEAR", newYearsEve, date(2024, time.January, 1), date(2025, time.January, 1)},
+		{"LAST_3_MONTHS", newYearsEve, date(2024, time.October, 1), newYearsEve},
+		{"THIS_WEEK", leapDay, date(2024, time.February, 26), date(2024, time.March, 4)},
+		{"THIS_MONTH", leapDay, date(2024, time.February, 1), date(2024, time.March, 1)},
+		{"LAST_6_MONTHS", leapDay, date(2023, time.August, 29), leapDay},
+	}
+	for _, test := range table {
+		start, end, err := ResolvePreset(test.preset, test.now)
+		if err != nil {
+			t.Errorf("%s: %v", test.preset, err)
+			continue
+		}
+		if !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("%s at %v: expected %v - %v, got %v - %v", test.preset, test.now, test.start, test.end, start, end)
+		}
+	}
+
+	_, _, err = ResolvePreset("CUSTOM", now)
+	if err == nil {
+		t.Errorf("expected an error for an unknown preset")
+	}
+}
+
+func TestRender(t *testing.T) {
+	stats := &v1.StatsResponse{
+		Categories: []*v1.CategoryStat{
+			{Name: "work|play", Time: durationpb.New(3 * time.Hour), Proportion: 0.75, Events: 2},
+			{Name: "Unknown", Time: durationpb.New(time.Hour), Proportion: 0.25},
+			{Name: "sleep", Time: durationpb.New(0), Disabled: true},
+		},
+		Total: durationpb.New(4 * time.Hour),
+	}
+
+	table := []struct {
+		format Format
+		expect string
+	}{
+		{FormatCSV, "category,seconds,proportion,events\nwork|play,10800,0.75,2\nUnknown,3600,0.25,0\n"},
+		{FormatMarkdown, "| Category | Time | Share | Events |\n" +
+			"| --- | ---: | ---: | ---: |\n" +
+			"| work\\|play | 3 hours | 75.0% | 2 |\n" +
+			"| Unknown | 1 hour | 25.0% | 0 |\n" +
+			"| **Total** | 4 hours | | |\n"},
+	}
+	for _, test := range table {
+		var b strings.Builder
+		err := Render(&b, stats, test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.expect {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.format, test.expect, b.String())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Package rules assigns tags to events that match configured patterns.
+package rules
+
+import (
+	"calstats/internal/config"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Subject is what the patterns of a rule are matched against.
+type Subject struct {
+	Name        string
+	Location    string
+	Description string
+	Calendar    string
+	Source      string
+	// the event has tags of its own
+	Tagged bool
+}
+
+type condition struct {
+	value   func(Subject) string
+	pattern *regexp.Regexp
+}
+
+// Rule is a compiled config.Rule.
+type Rule struct {
+	Name       string
+	Tags       []string
+	override   bool
+	conditions []condition
+}
+
+// Matches reports whether every condition of the rule matches the subject.
+func (r Rule) Matches(s Subject) bool {
+	if s.Tagged && !r.override {
+		return false
+	}
+	for _, c := range r.conditions {
+		if !c.pattern.MatchString(c.value(s)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rules is an ordered list of rules.
+type Rules []Rule
+
+// Compile compiles the patterns of the rules.
+func Compile(cfg []config.Rule) (Rules, error) {
+	out := make(Rules, len(cfg))
+	for i, r := range cfg {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(r.Tags) == 0 {
+			return nil, fmt.Errorf("rule %s: no tags", name)
+		}
+		out[i] = Rule{
+			Name:     name,
+			Tags:     r.Tags,
+			override: r.Override,
+		}
+
+		fields := []struct {
+			pattern string
+			value   func(Subject) string
+		}{
+			{r.Match.Name, func(s Subject) string { return s.Name }},
+			{r.Match.Location, func(s Subject) string { return s.Location }},
+			{r.Match.Description, func(s Subject) string { return s.Description }},
+			{r.Match.Calendar, func(s Subject) string { return s.Calendar }},
+			{r.Match.Source, func(s Subject) string { return s.Source }},
+		}
+		for _, f := range fields {
+			if f.pattern == "" {
+				continue
+			}
+			pattern, err := compilePattern(f.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", name, err)
+			}
+			out[i].conditions = append(out[i].conditions, condition{
+				value:   f.value,
+				pattern: pattern,
+			})
+		}
+	}
+	return out, nil
+}
+
+// Match returns the first rule matching the subject.
+func (rules Rules) Match(s Subject) (Rule, bool) {
+	for _, r := range rules {
+		if r.Matches(s) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// compilePattern compiles "re:<regex>" as a regular expression and anything
+// else as a glob.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("pattern '%s': %w", pattern, err)
+		}
+		return re, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"calstats/internal/config"
+	"slices"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	ruleset, err := Compile([]config.Rule{
+		{
+			Name:  "standups",
+			Match: config.RuleMatch{Name: "standup*"},
+			Tags:  []string{"meeting"},
+		},
+		{
+			Name:  "tickets",
+			Match: config.RuleMatch{Description: `re:\bPRJ-\d+\b`},
+			Tags:  []string{"project"},
+		},
+		{
+			Match: config.RuleMatch{Calendar: "work", Location: "office ?"},
+			Tags:  []string{"work", "office"},
+		},
+		{
+			Name:     "gym",
+			Match:    config.RuleMatch{Source: "/home/*/calendars/sport*"},
+			Tags:     []string{"sport"},
+			Override: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		subject Subject
+		rule    string
+		tags    []string
+	}{
+		{Subject{Name: "Standup team A"}, "standups", []string{"meeting"}},
+		{Subject{Name: "Daily standup"}, "", nil},
+		// earlier rules win
+		{Subject{Name: "STANDUP", Description: "PRJ-12"}, "standups", []string{"meeting"}},
+		{Subject{Description: "review of PRJ-42 with team"}, "tickets", []string{"project"}},
+		{Subject{Description: "PRJ-42a"}, "", nil},
+		// every condition of a rule must match
+		{Subject{Calendar: "Work", Location: "Office 3"}, "#3", []string{"work", "office"}},
+		{Subject{Calendar: "Work", Location: "Office 12"}, "", nil},
+		{Subject{Calendar: "Private", Location: "Office 3"}, "", nil},
+		// events with categories are only matched by overriding rules
+		{Subject{Name: "Standup", Tagged: true}, "", nil},
+		{Subject{Source: "/home/me/calendars/sport.ics", Tagged: true}, "gym", []string{"sport"}},
+	}
+	for _, test := range table {
+		rule, ok := ruleset.Match(test.subject)
+		if ok != (test.rule != "") || rule.Name != test.rule || !slices.Equal(rule.Tags, test.tags) {
+			t.Errorf("%+v: expected rule '%s' with %v, got '%s' with %v", test.subject, test.rule, test.tags, rule.Name, rule.Tags)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	table := [][]config.Rule{
+		{{Match: config.RuleMatch{Name: "x"}}},
+		{{Match: config.RuleMatch{Name: "re:("}, Tags: []string{"x"}}},
+	}
+	for _, cfg := range table {
+		_, err := Compile(cfg)
+		if err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+}